@@ -0,0 +1,184 @@
+package tonconnect
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Address is a TON account address. Flags of the user-friendly form are not
+// part of the identity, so two Address values can be compared with ==.
+type Address struct {
+	Workchain int32
+	Hash      [32]byte
+}
+
+type addressFormat struct {
+	Bounceable bool
+	Testnet    bool
+	URLSafe    bool
+}
+
+type addressOpt = func(*addressFormat)
+
+const (
+	addrFlagBounceable    byte = 0x11
+	addrFlagNonBounceable byte = 0x51
+	addrFlagTestnet       byte = 0x80
+)
+
+// ParseAddress parses both the raw (0:abcd...) and the user-friendly base64
+// or base64url forms of an address.
+func ParseAddress(s string) (Address, error) {
+	if strings.Contains(s, ":") {
+		return parseRawAddress(s)
+	}
+
+	return parseUserFriendlyAddress(s)
+}
+
+func parseRawAddress(s string) (Address, error) {
+	var addr Address
+
+	// The user-friendly form stores the workchain in a single byte.
+	wc, h, _ := strings.Cut(s, ":")
+	workchain, err := strconv.ParseInt(wc, 10, 8)
+	if err != nil {
+		return addr, fmt.Errorf("tonconnect: failed to parse address workchain: %w", err)
+	}
+
+	data, err := hex.DecodeString(h)
+	if err != nil || len(data) != len(addr.Hash) {
+		return addr, fmt.Errorf("tonconnect: failed to parse address hash")
+	}
+
+	addr.Workchain = int32(workchain)
+	copy(addr.Hash[:], data)
+
+	return addr, nil
+}
+
+func parseUserFriendlyAddress(s string) (Address, error) {
+	var addr Address
+
+	if len(s) != 48 {
+		return addr, fmt.Errorf("tonconnect: address %q has invalid length", s)
+	}
+
+	enc := base64.URLEncoding
+	if strings.ContainsAny(s, "+/") {
+		enc = base64.StdEncoding
+	}
+	data, err := enc.DecodeString(s)
+	if err != nil {
+		return addr, fmt.Errorf("tonconnect: failed to decode address: %w", err)
+	}
+
+	if crc16(data[:34]) != binary.BigEndian.Uint16(data[34:]) {
+		return addr, fmt.Errorf("tonconnect: address %q checksum mismatch", s)
+	}
+
+	flag := data[0] &^ addrFlagTestnet
+	if flag != addrFlagBounceable && flag != addrFlagNonBounceable {
+		return addr, fmt.Errorf("tonconnect: address %q has unknown flags", s)
+	}
+
+	addr.Workchain = int32(int8(data[1]))
+	copy(addr.Hash[:], data[2:34])
+
+	return addr, nil
+}
+
+// Raw returns the address in the raw form used by wallets, e.g. 0:abcd....
+func (a Address) Raw() string {
+	return fmt.Sprintf("%d:%s", a.Workchain, hex.EncodeToString(a.Hash[:]))
+}
+
+// Format returns the user-friendly form of the address. By default it is
+// bounceable, for mainnet and base64url encoded. It panics if the workchain
+// doesn't fit in a byte, which ParseAddress never returns.
+func (a Address) Format(options ...addressOpt) string {
+	if a.Workchain < math.MinInt8 || a.Workchain > math.MaxInt8 {
+		panic(fmt.Sprintf("tonconnect: address workchain %d out of range", a.Workchain))
+	}
+
+	opts := &addressFormat{Bounceable: true, URLSafe: true}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	data := make([]byte, 36)
+	data[0] = addrFlagBounceable
+	if !opts.Bounceable {
+		data[0] = addrFlagNonBounceable
+	}
+	if opts.Testnet {
+		data[0] |= addrFlagTestnet
+	}
+	data[1] = byte(int8(a.Workchain))
+	copy(data[2:34], a.Hash[:])
+	binary.BigEndian.PutUint16(data[34:], crc16(data[:34]))
+
+	if opts.URLSafe {
+		return base64.URLEncoding.EncodeToString(data)
+	}
+
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func (a Address) String() string {
+	return a.Format()
+}
+
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Raw()), nil
+}
+
+func (a *Address) UnmarshalText(text []byte) error {
+	addr, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = addr
+
+	return nil
+}
+
+func WithBounceable(bounceable bool) addressOpt {
+	return func(opts *addressFormat) {
+		opts.Bounceable = bounceable
+	}
+}
+
+func WithTestnetFlag() addressOpt {
+	return func(opts *addressFormat) {
+		opts.Testnet = true
+	}
+}
+
+func WithStdEncoding() addressOpt {
+	return func(opts *addressFormat) {
+		opts.URLSafe = false
+	}
+}
+
+// crc16 is CRC-16/XMODEM used by user-friendly addresses.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
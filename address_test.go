@@ -0,0 +1,124 @@
+package tonconnect
+
+import (
+	"strings"
+	"testing"
+)
+
+// Vectors produced by tonutils-go.
+var testAddresses = []struct {
+	Raw                  string
+	Bounceable           string
+	NonBounceable        string
+	NonBounceableTestnet string
+}{
+	{
+		Raw:                  "0:e8275866cbf174de95888f0ad3373f99dbc883bec5f3df48503550bcea934337",
+		Bounceable:           "EQDoJ1hmy_F03pWIjwrTNz-Z28iDvsXz30hQNVC86pNDN4ga",
+		NonBounceable:        "UQDoJ1hmy_F03pWIjwrTNz-Z28iDvsXz30hQNVC86pNDN9Xf",
+		NonBounceableTestnet: "0QDoJ1hmy_F03pWIjwrTNz-Z28iDvsXz30hQNVC86pNDN25V",
+	},
+	{
+		Raw:                  "0:e71f2b5f35e5cd52f7dd471e359e5b15a93fc3b88fd6bc5cccacd9d5afb9fc85",
+		Bounceable:           "EQDnHytfNeXNUvfdRx41nlsVqT_DuI_WvFzMrNnVr7n8hWAO",
+		NonBounceable:        "UQDnHytfNeXNUvfdRx41nlsVqT_DuI_WvFzMrNnVr7n8hT3L",
+		NonBounceableTestnet: "0QDnHytfNeXNUvfdRx41nlsVqT_DuI_WvFzMrNnVr7n8hYZB",
+	},
+	{
+		Raw:                  "0:861ecaab3f815458ace30bc660d99f9be7943705f4edf91452f3a59d0e5d90e8",
+		Bounceable:           "EQCGHsqrP4FUWKzjC8Zg2Z-b55Q3BfTt-RRS86WdDl2Q6HAM",
+		NonBounceable:        "UQCGHsqrP4FUWKzjC8Zg2Z-b55Q3BfTt-RRS86WdDl2Q6C3J",
+		NonBounceableTestnet: "0QCGHsqrP4FUWKzjC8Zg2Z-b55Q3BfTt-RRS86WdDl2Q6JZD",
+	},
+	{
+		Raw:                  "-1:d50b59158ed8621335431cf29d4ddf57403ee8f31e573cefe5f45372341973dd",
+		Bounceable:           "Ef_VC1kVjthiEzVDHPKdTd9XQD7o8x5XPO_l9FNyNBlz3fUd",
+		NonBounceable:        "Uf_VC1kVjthiEzVDHPKdTd9XQD7o8x5XPO_l9FNyNBlz3ajY",
+		NonBounceableTestnet: "0f_VC1kVjthiEzVDHPKdTd9XQD7o8x5XPO_l9FNyNBlz3RNS",
+	},
+}
+
+func TestAddressFormat(t *testing.T) {
+	for _, tt := range testAddresses {
+		t.Run(tt.Raw, func(t *testing.T) {
+			addr, err := ParseAddress(tt.Raw)
+			if err != nil {
+				t.Fatalf("ParseAddress() error = %v", err)
+			}
+
+			if got := addr.Raw(); got != tt.Raw {
+				t.Errorf("Raw() = %q, want %q", got, tt.Raw)
+			}
+			if got := addr.String(); got != tt.Bounceable {
+				t.Errorf("String() = %q, want %q", got, tt.Bounceable)
+			}
+			if got := addr.Format(WithBounceable(false)); got != tt.NonBounceable {
+				t.Errorf("Format(non-bounceable) = %q, want %q", got, tt.NonBounceable)
+			}
+			if got := addr.Format(WithBounceable(false), WithTestnetFlag()); got != tt.NonBounceableTestnet {
+				t.Errorf("Format(non-bounceable, testnet) = %q, want %q", got, tt.NonBounceableTestnet)
+			}
+			std := strings.NewReplacer("-", "+", "_", "/").Replace(tt.Bounceable)
+			if got := addr.Format(WithStdEncoding()); got != std {
+				t.Errorf("Format(std encoding) = %q, want %q", got, std)
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, tt := range testAddresses {
+		forms := []string{
+			tt.Bounceable,
+			tt.NonBounceable,
+			tt.NonBounceableTestnet,
+			strings.NewReplacer("-", "+", "_", "/").Replace(tt.NonBounceable),
+		}
+		for _, s := range forms {
+			addr, err := ParseAddress(s)
+			if err != nil {
+				t.Errorf("ParseAddress(%q) error = %v", s, err)
+				continue
+			}
+			if got := addr.Raw(); got != tt.Raw {
+				t.Errorf("ParseAddress(%q) = %q, want %q", s, got, tt.Raw)
+			}
+		}
+	}
+}
+
+func TestParseAddressErrors(t *testing.T) {
+	valid := testAddresses[0].Bounceable
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{name: "empty"},
+		{name: "bad checksum", address: valid[:len(valid)-1] + "b"},
+		{name: "short", address: valid[:len(valid)-4]},
+		{name: "unknown flag", address: "A" + valid[1:]},
+		{name: "raw without workchain", address: "e8275866cbf174de95888f0ad3373f99dbc883bec5f3df48503550bcea934337"},
+		{name: "raw short hash", address: "0:e8275866"},
+		{name: "raw bad hex", address: "0:" + strings.Repeat("zz", 32)},
+		{name: "raw workchain out of range", address: "300:" + strings.Repeat("ab", 32)},
+		{name: "raw negative workchain out of range", address: "-129:" + strings.Repeat("ab", 32)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAddress(tt.address); err == nil {
+				t.Fatalf("ParseAddress(%q) error = nil", tt.address)
+			}
+		})
+	}
+}
+
+func TestAddressFormatWorkchainRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Format() with workchain 300 did not panic")
+		}
+	}()
+
+	Address{Workchain: 300}.Format()
+}
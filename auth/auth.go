@@ -0,0 +1,206 @@
+// Package auth issues and verifies JWTs for wallets that passed ton_proof
+// verification.
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cameo-engineering/tonconnect"
+)
+
+type Claims struct {
+	Address   string `json:"sub"`
+	Network   int64  `json:"network,string,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewClaims verifies the ton_proof of a connect response with
+// tonconnect.VerifyProof and returns claims for its account.
+func NewClaims(res *tonconnect.ConnectResponse, options ...tonconnect.ProofOption) (Claims, error) {
+	if err := tonconnect.VerifyProof(res, options...); err != nil {
+		return Claims{}, err
+	}
+
+	addr, _ := res.Address()
+	pubkey := addr.PublicKey
+	if pubkey == "" && len(addr.WalletStateInit) > 0 {
+		wsi, err := tonconnect.ParseWalletStateInit(addr.Address, addr.WalletStateInit)
+		if err != nil {
+			return Claims{}, err
+		}
+		pubkey = hex.EncodeToString(wsi.PublicKey)
+	}
+
+	return Claims{Address: addr.Address, Network: addr.Network, PublicKey: pubkey}, nil
+}
+
+// Authority signs and verifies tokens with either HS256 or EdDSA.
+type Authority struct {
+	alg    string
+	sign   func(msg []byte) ([]byte, error)
+	verify func(msg, sig []byte) bool
+	ttl    time.Duration
+	issuer string
+	now    func() time.Time
+}
+
+type authorityOpt = func(*Authority)
+
+var (
+	ErrInvalidToken = errors.New("auth: invalid token")
+	ErrTokenExpired = errors.New("auth: token has expired")
+)
+
+func NewHS256(secret []byte, options ...authorityOpt) *Authority {
+	mac := func(msg []byte) []byte {
+		h := hmac.New(sha256.New, secret)
+		h.Write(msg)
+		return h.Sum(nil)
+	}
+
+	return newAuthority("HS256",
+		func(msg []byte) ([]byte, error) {
+			return mac(msg), nil
+		},
+		func(msg, sig []byte) bool {
+			return hmac.Equal(mac(msg), sig)
+		},
+		options...,
+	)
+}
+
+func NewEdDSA(key ed25519.PrivateKey, options ...authorityOpt) *Authority {
+	pub := key.Public().(ed25519.PublicKey)
+
+	return newAuthority("EdDSA",
+		func(msg []byte) ([]byte, error) {
+			return ed25519.Sign(key, msg), nil
+		},
+		func(msg, sig []byte) bool {
+			return ed25519.Verify(pub, msg, sig)
+		},
+		options...,
+	)
+}
+
+// NewEdDSAVerifier returns an Authority that can only verify tokens, e.g. in
+// services that do not issue them.
+func NewEdDSAVerifier(key ed25519.PublicKey, options ...authorityOpt) *Authority {
+	return newAuthority("EdDSA",
+		func([]byte) ([]byte, error) {
+			return nil, fmt.Errorf("auth: authority has no signing key")
+		},
+		func(msg, sig []byte) bool {
+			return ed25519.Verify(key, msg, sig)
+		},
+		options...,
+	)
+}
+
+func newAuthority(alg string, sign func([]byte) ([]byte, error), verify func([]byte, []byte) bool, options ...authorityOpt) *Authority {
+	a := &Authority{alg: alg, sign: sign, verify: verify, ttl: 24 * time.Hour, now: time.Now}
+	for _, opt := range options {
+		opt(a)
+	}
+
+	return a
+}
+
+// Issue returns a signed JWT for claims. IssuedAt, ExpiresAt and Issuer are
+// filled in by the authority.
+func (a *Authority) Issue(claims Claims) (string, error) {
+	now := a.now()
+	claims.Issuer = a.issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(a.ttl).Unix()
+
+	header, err := json.Marshal(map[string]string{"alg": a.alg, "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("auth: failed to marshal token header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("auth: failed to marshal token claims: %w", err)
+	}
+
+	msg := encode(header) + "." + encode(payload)
+	sig, err := a.sign([]byte(msg))
+	if err != nil {
+		return "", err
+	}
+
+	return msg + "." + encode(sig), nil
+}
+
+func (a *Authority) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	header, err := decode(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != a.alg {
+		return nil, fmt.Errorf("%w: unexpected algorithm", ErrInvalidToken)
+	}
+
+	sig, err := decode(parts[2])
+	if err != nil || !a.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+
+	payload, err := decode(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if a.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+func WithTTL(ttl time.Duration) authorityOpt {
+	return func(a *Authority) {
+		a.ttl = ttl
+	}
+}
+
+func WithIssuer(issuer string) authorityOpt {
+	return func(a *Authority) {
+		a.issuer = issuer
+	}
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
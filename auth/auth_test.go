@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameo-engineering/tonconnect"
+)
+
+func TestNewClaimsVerifiesProof(t *testing.T) {
+	res := &tonconnect.ConnectResponse{Items: []tonconnect.ConnectItemReply{
+		{Name: "ton_addr", TonAddrItem: tonconnect.TonAddrItem{
+			Address:   "0:e71f2b5f35e5cd52f7dd471e359e5b15a93fc3b88fd6bc5cccacd9d5afb9fc85",
+			PublicKey: "79b5562e8fe654f94078b112e8a98ba7901f853ae695bed7e0e3910bad049664",
+		}},
+		{Name: "ton_proof", Proof: tonconnect.TonProofItem{
+			Timestamp: uint64(time.Now().Unix()),
+			Domain:    tonconnect.TonProofDomain{LengthBytes: 11, Value: "example.com"},
+			Signature: make([]byte, ed25519.SignatureSize),
+		}},
+	}}
+
+	if _, err := NewClaims(res, tonconnect.WithAllowedDomains("example.com")); !errors.Is(err, tonconnect.ErrInvalidProof) {
+		t.Fatalf("NewClaims() error = %v, want %v", err, tonconnect.ErrInvalidProof)
+	}
+}
+
+func TestAuthority(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key := ed25519.NewKeyFromSeed(seed)
+	claims := Claims{Address: "0:e71f2b5f35e5cd52f7dd471e359e5b15a93fc3b88fd6bc5cccacd9d5afb9fc85", Network: -239}
+
+	tests := []struct {
+		name   string
+		issuer *Authority
+		verify *Authority
+		err    error
+	}{
+		{name: "HS256", issuer: NewHS256([]byte("secret")), verify: NewHS256([]byte("secret"))},
+		{name: "EdDSA", issuer: NewEdDSA(key), verify: NewEdDSAVerifier(key.Public().(ed25519.PublicKey))},
+		{name: "wrong secret", issuer: NewHS256([]byte("secret")), verify: NewHS256([]byte("other")), err: ErrInvalidToken},
+		{name: "algorithm mismatch", issuer: NewHS256([]byte("secret")), verify: NewEdDSA(key), err: ErrInvalidToken},
+		{name: "wrong issuer", issuer: NewHS256([]byte("secret"), WithIssuer("a")), verify: NewHS256([]byte("secret"), WithIssuer("b")), err: ErrInvalidToken},
+		{name: "expired", issuer: NewHS256([]byte("secret"), WithTTL(-time.Second)), verify: NewHS256([]byte("secret")), err: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.issuer.Issue(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.verify.Verify(token)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got.Address != claims.Address || got.Network != claims.Network {
+				t.Errorf("Verify() = %+v, want %+v", got, claims)
+			}
+
+			// Any change to the claims invalidates the signature.
+			parts := strings.Split(token, ".")
+			forged, _ := tt.issuer.Issue(Claims{Address: "0:" + strings.Repeat("00", 32)})
+			parts[1] = strings.Split(forged, ".")[1]
+			if _, err := tt.verify.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() of tampered token error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := NewHS256([]byte("secret"))
+	token, err := a.Issue(Claims{Address: "0:" + strings.Repeat("ab", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := FromContext(r.Context())
+		if !ok {
+			t.Error("claims are missing from the request context")
+			return
+		}
+		w.Write([]byte(claims.Address))
+	}))
+
+	for _, tt := range []struct {
+		header string
+		status int
+	}{
+		{header: "Bearer " + token, status: http.StatusOK},
+		{header: "", status: http.StatusUnauthorized},
+		{header: "Bearer " + token + "x", status: http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.header, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package tonconnect
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math/bits"
+)
+
+// cell is a minimal read-only TON cell, enough to inspect wallet state inits.
+type cell struct {
+	data   []byte
+	bits   int
+	refs   []*cell
+	exotic bool
+	level  byte
+	hash   [32]byte
+	depth  uint16
+}
+
+type cellReader struct {
+	c   *cell
+	pos int
+	ref int
+}
+
+const (
+	bocMagic = 0xb5ee9c72
+	// maxBoCCells limits the bags of cells this parser accepts. Standard
+	// wallet state inits take a few dozen cells.
+	maxBoCCells = 256
+)
+
+// parseBoC deserializes a bag of cells and returns its first root.
+func parseBoC(boc []byte) (*cell, error) {
+	r := boc
+	if len(r) < 6 || binary.BigEndian.Uint32(r) != bocMagic {
+		return nil, fmt.Errorf("tonconnect: invalid bag of cells magic")
+	}
+
+	flags := r[4]
+	hasIdx := flags&0x80 != 0
+	hasCRC := flags&0x40 != 0
+	size := int(flags & 0x07)
+	offSize := int(r[5])
+	if size < 1 || size > 4 || offSize < 1 || offSize > 8 {
+		return nil, fmt.Errorf("tonconnect: invalid bag of cells header")
+	}
+
+	if hasCRC {
+		if len(r) < 4 {
+			return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+		}
+		body, sum := r[:len(r)-4], binary.LittleEndian.Uint32(r[len(r)-4:])
+		if crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)) != sum {
+			return nil, fmt.Errorf("tonconnect: bag of cells checksum mismatch")
+		}
+		r = body
+	}
+
+	r = r[6:]
+	readUint := func(n int) (int, error) {
+		if len(r) < n {
+			return 0, fmt.Errorf("tonconnect: bag of cells is too short")
+		}
+		var v uint64
+		for _, b := range r[:n] {
+			v = v<<8 | uint64(b)
+		}
+		r = r[n:]
+		if v > 1<<31 {
+			return 0, fmt.Errorf("tonconnect: bag of cells value is too large")
+		}
+		return int(v), nil
+	}
+
+	cellsCount, err := readUint(size)
+	if err != nil {
+		return nil, err
+	}
+	rootsCount, err := readUint(size)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readUint(size); err != nil { // absent
+		return nil, err
+	}
+	dataSize, err := readUint(offSize)
+	if err != nil {
+		return nil, err
+	}
+	if rootsCount < 1 || cellsCount < rootsCount {
+		return nil, fmt.Errorf("tonconnect: bag of cells has no roots")
+	}
+	// Every cell takes at least two descriptor bytes, check the untrusted
+	// header before allocating anything for the cells.
+	if cellsCount > maxBoCCells {
+		return nil, fmt.Errorf("tonconnect: bag of cells has too many cells")
+	}
+	if cellsCount > dataSize/2 || dataSize > len(r) {
+		return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+	}
+
+	root, err := readUint(size)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < rootsCount; i++ {
+		if _, err := readUint(size); err != nil {
+			return nil, err
+		}
+	}
+	if hasIdx {
+		// The index only speeds up random access, skip it.
+		if len(r) < cellsCount*offSize {
+			return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+		}
+		r = r[cellsCount*offSize:]
+	}
+	if len(r) < dataSize {
+		return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+	}
+	r = r[:dataSize]
+
+	cells := make([]*cell, cellsCount)
+	refIdx := make([][]int, cellsCount)
+	for i := range cells {
+		if len(r) < 2 {
+			return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+		}
+		d1, d2 := r[0], r[1]
+		r = r[2:]
+
+		c := &cell{exotic: d1&8 != 0, level: d1 >> 5}
+		if d1&16 != 0 {
+			n := (bits.OnesCount8(c.level) + 1) * (32 + 2)
+			if len(r) < n {
+				return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+			}
+			r = r[n:]
+		}
+
+		n := int(d2+1) / 2
+		if len(r) < n {
+			return nil, fmt.Errorf("tonconnect: bag of cells is too short")
+		}
+		c.data = r[:n]
+		r = r[n:]
+		c.bits = n * 8
+		if d2%2 == 1 {
+			last := c.data[n-1]
+			if last == 0 {
+				return nil, fmt.Errorf("tonconnect: invalid cell completion tag")
+			}
+			c.bits -= bits.TrailingZeros8(last) + 1
+		}
+
+		for j := 0; j < int(d1&7); j++ {
+			idx, err := readUint(size)
+			if err != nil {
+				return nil, err
+			}
+			if idx <= i || idx >= cellsCount {
+				return nil, fmt.Errorf("tonconnect: invalid cell reference")
+			}
+			refIdx[i] = append(refIdx[i], idx)
+		}
+
+		cells[i] = c
+	}
+
+	// References always point forward, so hash cells from the end.
+	for i := cellsCount - 1; i >= 0; i-- {
+		c := cells[i]
+		for _, idx := range refIdx[i] {
+			c.refs = append(c.refs, cells[idx])
+		}
+		if err := c.computeHash(); err != nil {
+			return nil, err
+		}
+	}
+
+	if root >= cellsCount {
+		return nil, fmt.Errorf("tonconnect: invalid bag of cells root")
+	}
+
+	return cells[root], nil
+}
+
+func (c *cell) computeHash() error {
+	if c.level != 0 {
+		return fmt.Errorf("tonconnect: cells with non-zero level are not supported")
+	}
+
+	h := sha256.New()
+	d1 := byte(len(c.refs))
+	if c.exotic {
+		d1 |= 8
+	}
+	d2 := byte(c.bits/8 + (c.bits+7)/8)
+	h.Write([]byte{d1, d2})
+	h.Write(c.data)
+
+	for _, ref := range c.refs {
+		if ref.depth+1 > c.depth {
+			c.depth = ref.depth + 1
+		}
+		h.Write(binary.BigEndian.AppendUint16(nil, ref.depth))
+	}
+	for _, ref := range c.refs {
+		h.Write(ref.hash[:])
+	}
+
+	copy(c.hash[:], h.Sum(nil))
+
+	return nil
+}
+
+func (c *cell) reader() *cellReader {
+	return &cellReader{c: c}
+}
+
+func (r *cellReader) readBits(n int) (uint64, error) {
+	if n > 64 || r.pos+n > r.c.bits {
+		return 0, fmt.Errorf("tonconnect: not enough bits in cell")
+	}
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		b := r.c.data[(r.pos+i)/8] >> (7 - (r.pos+i)%8) & 1
+		v = v<<1 | uint64(b)
+	}
+	r.pos += n
+
+	return v, nil
+}
+
+func (r *cellReader) skipBits(n int) error {
+	if r.pos+n > r.c.bits {
+		return fmt.Errorf("tonconnect: not enough bits in cell")
+	}
+	r.pos += n
+
+	return nil
+}
+
+func (r *cellReader) readBytes(n int) ([]byte, error) {
+	res := make([]byte, n)
+	for i := range res {
+		b, err := r.readBits(8)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = byte(b)
+	}
+
+	return res, nil
+}
+
+func (r *cellReader) readRef() (*cell, error) {
+	if r.ref >= len(r.c.refs) {
+		return nil, fmt.Errorf("tonconnect: not enough references in cell")
+	}
+	r.ref++
+
+	return r.c.refs[r.ref-1], nil
+}
@@ -3,15 +3,14 @@ package tonconnect
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strconv"
 
 	"golang.org/x/sync/errgroup"
 )
 
-type connectResponse struct {
-	Device deviceInfo         `json:"device,omitempty"`
-	Items  []connectItemReply `json:"items,omitempty"`
+type ConnectResponse struct {
+	Device DeviceInfo         `json:"device,omitempty"`
+	Items  []ConnectItemReply `json:"items,omitempty"`
 }
 
 type disconnectRequest struct {
@@ -20,13 +19,13 @@ type disconnectRequest struct {
 	Params []any  `json:"params"`
 }
 
-func (s *Session) Connect(ctx context.Context, wallets ...Wallet) (*connectResponse, error) {
+func (s *Session) Connect(ctx context.Context, wallets ...Wallet) (*ConnectResponse, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	g, ctx := errgroup.WithContext(ctx)
 	msgs := make(chan bridgeMessage)
 
-	res := &connectResponse{}
+	res := &ConnectResponse{}
 	g.Go(func() error {
 		for {
 			select {
@@ -38,14 +37,26 @@ func (s *Session) Connect(ctx context.Context, wallets ...Wallet) (*connectRespo
 
 					msgID, err := msg.Message.ID.Int64()
 					if err == nil {
-						s.LastRequestID = uint64(msgID)
+						s.observeRequestID(uint64(msgID))
 					}
 
+					res.Items, err = getConnectItems(msg.Message.Payload.Items...)
+					res.Device = msg.Message.Payload.Device
+
+					s.mu.Lock()
 					s.ClientID = msg.From
 					s.BridgeURL = msg.BrdigeURL
+					device := res.Device
+					s.Device = &device
+					s.Account = nil
+					if addr, ok := res.Address(); ok {
+						s.Account = &addr
+					}
+					s.mu.Unlock()
+					if err := s.checkpoint(ctx); err != nil {
+						return err
+					}
 
-					res.Items, err = getConnectItems(msg.Message.Payload.Items...)
-					res.Device = msg.Message.Payload.Device
 					return err
 				} else if msg.Message.Event == "connect_error" {
 					return getConnectError(msg.Message.Payload)
@@ -58,7 +69,7 @@ func (s *Session) Connect(ctx context.Context, wallets ...Wallet) (*connectRespo
 		u := u
 
 		g.Go(func() error {
-			return s.connectToBridge(ctx, u, msgs)
+			return s.connectToBridge(ctx, u, msgs, nil)
 		})
 	}
 
@@ -68,109 +79,59 @@ func (s *Session) Connect(ctx context.Context, wallets ...Wallet) (*connectRespo
 }
 
 func (s *Session) Disconnect(ctx context.Context, options ...bridgeMessageOption) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	g, ctx := errgroup.WithContext(ctx)
-	msgs := make(chan bridgeMessage)
-
-	id := s.LastRequestID + 1
-	g.Go(func() error {
-		req := disconnectRequest{
-			ID:     strconv.FormatUint(id, 10),
-			Method: "disconnect",
-			Params: []any{},
-		}
-
-		err := s.sendMessage(ctx, req, "", options...)
-		if err == nil {
-			s.LastRequestID = id
-		}
+	id := s.nextRequestID()
+	req := disconnectRequest{
+		ID:     strconv.FormatUint(id, 10),
+		Method: "disconnect",
+		Params: []any{},
+	}
 
+	msg, err := s.request(ctx, id, req, "", options...)
+	if err != nil {
 		return err
-	})
-
-	g.Go(func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case msg := <-msgs:
-				msgID, err := msg.Message.ID.Int64()
-				if err == nil {
-					s.LastRequestID = uint64(msgID)
-				}
+	}
 
-				if int64(id) == msgID {
-					cancel()
+	if msg.Error != nil {
+		return newProtocolError("disconnect", *msg.Error)
+	}
 
-					if msg.Message.Error != nil {
-						if msg.Message.Error.Message != "" {
-							return fmt.Errorf("tonconnect: %s", msg.Message.Error.Message)
-						}
-
-						switch msg.Message.Error.Code {
-						case 1:
-							return fmt.Errorf("tonconnect: bad request")
-						case 100:
-							return fmt.Errorf("tonconnect: unknown app")
-						case 400:
-							return fmt.Errorf("tonconnect: %q method is not supported", "sendTransaction")
-						default:
-							return fmt.Errorf("tonconnect: unknown disconnection error")
-						}
-					}
-				}
+	s.reset()
 
-				return nil
-			}
-		}
-	})
+	return s.checkpoint(ctx)
+}
 
-	g.Go(func() error {
-		return s.connectToBridge(ctx, s.BridgeURL, msgs)
-	})
+func getConnectError(payload EventPayload) error {
+	return newProtocolError("connect", WalletError{Code: payload.Code, Message: payload.Message})
+}
 
-	err := g.Wait()
+// Address returns the reply to the "ton_addr" item.
+func (r *ConnectResponse) Address() (TonAddrItem, bool) {
+	for _, item := range r.Items {
+		if item.Name == "ton_addr" {
+			return item.TonAddrItem, true
+		}
+	}
 
-	return err
+	return TonAddrItem{}, false
 }
 
-func getConnectError(payload payload) error {
-	if payload.Message != "" {
-		return fmt.Errorf("tonconnect: %s", payload.Message)
+// Proof returns the reply to the "ton_proof" item.
+func (r *ConnectResponse) Proof() (TonProofItem, bool) {
+	for _, item := range r.Items {
+		if item.Name == "ton_proof" {
+			return item.Proof, true
+		}
 	}
 
-	switch payload.Code {
-	case 1:
-		return fmt.Errorf("tonconnect: bad request")
-	case 2:
-		return fmt.Errorf("tonconnect: app manifest not found")
-	case 3:
-		return fmt.Errorf("tonconnect: app manifest content error")
-	case 100:
-		return fmt.Errorf("tonconnect: unknown app")
-	case 300:
-		return fmt.Errorf("tonconnect: user declined the connection")
-	default:
-		return fmt.Errorf("tonconnect: unknown connection error")
-	}
+	return TonProofItem{}, false
 }
 
-func getConnectItems(items ...connectItemReply) ([]connectItemReply, error) {
+func getConnectItems(items ...ConnectItemReply) ([]ConnectItemReply, error) {
 	var errs []error
-	var res []connectItemReply
+	var res []ConnectItemReply
 	for _, item := range items {
 		if item.Error != nil {
-			if item.Error.Message != "" {
-				errs = append(errs, fmt.Errorf("tonconnect: %s", item.Error.Message))
-			} else {
-				switch item.Error.Code {
-				case 400:
-					errs = append(errs, fmt.Errorf("tonconnect: %q method is not supported", item.Name))
-				default:
-					errs = append(errs, fmt.Errorf("tonconnect: %q method unknown error", item.Name))
-				}
-			}
+			errs = append(errs, newProtocolError(item.Name, *item.Error))
 		} else {
 			res = append(res, item)
 		}
@@ -0,0 +1,139 @@
+package tonconnect
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/kevinburke/nacl"
+)
+
+// Encrypter protects session private keys at rest. It can be backed by a
+// local key (see NewAESEncrypter) or by an external KMS.
+type Encrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type AESEncrypter struct {
+	aead cipher.AEAD
+}
+
+// sealedKey is a session private key sealed with a random data key, which is
+// in turn encrypted by the session Encrypter.
+type sealedKey struct {
+	Key  []byte `json:"key"`
+	Data []byte `json:"data"`
+}
+
+// NewAESEncrypter returns an AES-GCM Encrypter. The key must be 16, 24 or 32
+// bytes long.
+func NewAESEncrypter(key []byte) (*AESEncrypter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESEncrypter{aead: aead}, nil
+}
+
+func (e *AESEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	return gcmSeal(e.aead, plaintext)
+}
+
+func (e *AESEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return gcmOpen(e.aead, ciphertext)
+}
+
+func WithEncrypter(enc Encrypter) sessionOpt {
+	return func(s *Session) {
+		s.encrypter = enc
+	}
+}
+
+func sealPrivateKey(enc Encrypter, pk nacl.Key) (*sealedKey, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to generate data key: %w", err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := gcmSeal(aead, pk[:])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := enc.Encrypt(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to encrypt data key: %w", err)
+	}
+
+	return &sealedKey{Key: key, Data: data}, nil
+}
+
+func openPrivateKey(enc Encrypter, sealed *sealedKey) (nacl.Key, error) {
+	dataKey, err := enc.Decrypt(sealed.Key)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to decrypt data key: %w", err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := gcmOpen(aead, sealed.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != nacl.KeySize {
+		return nil, fmt.Errorf("tonconnect: private key has invalid length %d", len(data))
+	}
+
+	pk := new([nacl.KeySize]byte)
+	copy(pk[:], data)
+
+	return pk, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to initialize AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to initialize AES-GCM: %w", err)
+	}
+
+	return aead, nil
+}
+
+func gcmSeal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("tonconnect: ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,131 @@
+package tonconnect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknown            = errors.New("tonconnect: unknown error")
+	ErrBadRequest         = errors.New("tonconnect: bad request")
+	ErrManifestNotFound   = errors.New("tonconnect: app manifest not found")
+	ErrManifestContent    = errors.New("tonconnect: app manifest content error")
+	ErrUnknownApp         = errors.New("tonconnect: unknown app")
+	ErrUserDeclined       = errors.New("tonconnect: user declined the request")
+	ErrMethodNotSupported = errors.New("tonconnect: method is not supported")
+
+	ErrDisconnected = errors.New("tonconnect: wallet disconnected")
+	ErrStreamClosed = errors.New("tonconnect: bridge event stream closed")
+
+	ErrBridgeBadRequest  = errors.New("tonconnect: bridge rejected request parameters")
+	ErrBridgeRateLimited = errors.New("tonconnect: bridge rate limit exceeded")
+	ErrBridgeUnavailable = errors.New("tonconnect: bridge is unavailable")
+)
+
+// ProtocolError is an error reported by the wallet. It matches the sentinel
+// error for its code with errors.Is.
+type ProtocolError struct {
+	Code    uint64
+	Message string
+	Method  string
+}
+
+// BridgeError is a non-successful HTTP response from a bridge. Depending on
+// the status code it matches ErrBridgeBadRequest, ErrBridgeRateLimited or
+// ErrBridgeUnavailable with errors.Is.
+type BridgeError struct {
+	StatusCode int
+	Message    string
+	URL        string
+	RetryAfter time.Duration
+}
+
+var protocolErrors = map[uint64]error{
+	0:   ErrUnknown,
+	1:   ErrBadRequest,
+	2:   ErrManifestNotFound,
+	3:   ErrManifestContent,
+	100: ErrUnknownApp,
+	300: ErrUserDeclined,
+	400: ErrMethodNotSupported,
+}
+
+func (e *ProtocolError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.TrimPrefix(e.Unwrap().Error(), "tonconnect: ")
+	}
+
+	if e.Method != "" {
+		return fmt.Sprintf("tonconnect: %s: %s", e.Method, msg)
+	}
+
+	return "tonconnect: " + msg
+}
+
+func (e *ProtocolError) Unwrap() error {
+	if err, ok := protocolErrors[e.Code]; ok {
+		return err
+	}
+
+	return ErrUnknown
+}
+
+func newProtocolError(method string, e WalletError) error {
+	return &ProtocolError{Code: e.Code, Message: e.Message, Method: method}
+}
+
+func (e *BridgeError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+
+	return fmt.Sprintf("tonconnect: bridge responded with %d status code: %s", e.StatusCode, msg)
+}
+
+func (e *BridgeError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBridgeBadRequest
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrBridgeRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrBridgeUnavailable
+	default:
+		return nil
+	}
+}
+
+// Temporary reports whether the request may succeed if sent again later.
+func (e *BridgeError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// newBridgeError parses the bridge response body, which is expected to be
+// {"message": "...", "statusCode": 400} as in github.com/ton-connect/bridge.
+func newBridgeError(res *http.Response) *BridgeError {
+	e := &BridgeError{StatusCode: res.StatusCode, URL: res.Request.URL.String()}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	var v struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &v); err == nil {
+		e.Message = v.Message
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if secs, err := strconv.ParseUint(res.Header.Get("Retry-After"), 10, 32); err == nil {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+
+	return e
+}
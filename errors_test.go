@@ -0,0 +1,133 @@
+package tonconnect
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProtocolError(t *testing.T) {
+	tests := []struct {
+		code    uint64
+		method  string
+		message string
+		want    error
+		wantMsg string
+	}{
+		{code: 0, method: "sendTransaction", want: ErrUnknown, wantMsg: "tonconnect: sendTransaction: unknown error"},
+		{code: 1, method: "signData", message: "invalid payload", want: ErrBadRequest, wantMsg: "tonconnect: signData: invalid payload"},
+		{code: 2, want: ErrManifestNotFound, wantMsg: "tonconnect: app manifest not found"},
+		{code: 3, message: "bad icon", want: ErrManifestContent, wantMsg: "tonconnect: bad icon"},
+		{code: 100, method: "disconnect", want: ErrUnknownApp, wantMsg: "tonconnect: disconnect: unknown app"},
+		{code: 300, method: "sendTransaction", message: "Reject request", want: ErrUserDeclined, wantMsg: "tonconnect: sendTransaction: Reject request"},
+		{code: 400, method: "signData", want: ErrMethodNotSupported, wantMsg: "tonconnect: signData: method is not supported"},
+		{code: 42, method: "connect", want: ErrUnknown, wantMsg: "tonconnect: connect: unknown error"},
+	}
+
+	for _, tt := range tests {
+		err := newProtocolError(tt.method, WalletError{Code: tt.code, Message: tt.message})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("code %d: errors.Is(%v, %v) = false", tt.code, err, tt.want)
+		}
+		for _, other := range protocolErrors {
+			if other != tt.want && errors.Is(err, other) {
+				t.Errorf("code %d: error also matches %v", tt.code, other)
+			}
+		}
+		if got := err.Error(); got != tt.wantMsg {
+			t.Errorf("code %d: Error() = %q, want %q", tt.code, got, tt.wantMsg)
+		}
+
+		var perr *ProtocolError
+		if !errors.As(err, &perr) || perr.Code != tt.code || perr.Method != tt.method {
+			t.Errorf("code %d: errors.As() = %+v", tt.code, perr)
+		}
+	}
+}
+
+func TestBridgeError(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		header         map[string]string
+		body           string
+		want           error
+		wantMsg        string
+		wantRetryAfter time.Duration
+		wantTemporary  bool
+	}{
+		{
+			name:    "bad request",
+			status:  http.StatusBadRequest,
+			body:    `{"message":"param \"client_id\" not present","statusCode":400}`,
+			want:    ErrBridgeBadRequest,
+			wantMsg: `param "client_id" not present`,
+		},
+		{
+			name:           "rate limited",
+			status:         http.StatusTooManyRequests,
+			header:         map[string]string{"Retry-After": "7"},
+			want:           ErrBridgeRateLimited,
+			wantRetryAfter: 7 * time.Second,
+			wantTemporary:  true,
+		},
+		{
+			name:          "unavailable",
+			status:        http.StatusBadGateway,
+			body:          "upstream connect error\n",
+			want:          ErrBridgeUnavailable,
+			wantMsg:       "upstream connect error",
+			wantTemporary: true,
+		},
+		{
+			name:    "forbidden",
+			status:  http.StatusForbidden,
+			body:    `{"message":"forbidden"}`,
+			wantMsg: "forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL + "/message")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			berr := newBridgeError(res)
+			if berr.StatusCode != tt.status || berr.Message != tt.wantMsg || berr.URL != srv.URL+"/message" {
+				t.Errorf("newBridgeError() = %+v", berr)
+			}
+			if berr.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %v, want %v", berr.RetryAfter, tt.wantRetryAfter)
+			}
+			if berr.Temporary() != tt.wantTemporary {
+				t.Errorf("Temporary() = %v, want %v", berr.Temporary(), tt.wantTemporary)
+			}
+
+			for _, sentinel := range []error{ErrBridgeBadRequest, ErrBridgeRateLimited, ErrBridgeUnavailable} {
+				if got := errors.Is(berr, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", berr, sentinel, got)
+				}
+			}
+		})
+	}
+
+	berr := &BridgeError{StatusCode: http.StatusServiceUnavailable}
+	if got, want := berr.Error(), "tonconnect: bridge responded with 503 status code: Service Unavailable"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base32"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/cameo-engineering/tonconnect"
+	"github.com/cameo-engineering/tonconnect/qrcode"
 	"golang.org/x/exp/maps"
 )
 
@@ -18,15 +18,21 @@ func main() {
 		log.Fatal(err)
 	}
 
-	data := make([]byte, 32)
-	_, err = rand.Read(data)
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	issuer := tonconnect.NewProofPayloadIssuer(secret, 10*time.Minute)
+	payload, err := issuer.Issue()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	connreq, err := tonconnect.NewConnectRequest(
 		"https://raw.githubusercontent.com/cameo-engineering/tonconnect/master/tonconnect-manifest.json",
-		tonconnect.WithProofRequest(base32.StdEncoding.EncodeToString(data)),
+		tonconnect.WithProofRequest(payload),
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -36,18 +42,12 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("Deeplink: %s\n\n", deeplink)
-
-	wrapped := tonconnect.WrapDeeplink(deeplink)
-	fmt.Printf("Wrapped deeplink: %s\n\n", wrapped)
 
-	for _, wallet := range tonconnect.Wallets {
-		link, err := s.GenerateUniversalLink(wallet, *connreq)
-		fmt.Printf("%s: %s\n\n", wallet.Name, link)
-		if err != nil {
-			log.Fatal(err)
-		}
+	qr, err := qrcode.Terminal(deeplink)
+	if err != nil {
+		log.Fatal(err)
 	}
+	fmt.Printf("Scan the QR code with your wallet:\n\n%s\n", qr)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
@@ -57,15 +57,18 @@ func main() {
 		log.Fatal(err)
 	}
 
-	var addr string
+	if err := tonconnect.VerifyProof(
+		res,
+		tonconnect.WithAllowedDomains("cameo.engineering"),
+		tonconnect.WithPayloadIssuer(issuer),
+	); err != nil {
+		log.Fatal(err)
+	}
+
+	addr, _ := res.Address()
 	network := "mainnet"
-	for _, item := range res.Items {
-		if item.Name == "ton_addr" {
-			addr = item.Address
-			if item.Network == -3 {
-				network = "testnet"
-			}
-		}
+	if addr.Network == -3 {
+		network = "testnet"
 	}
 	fmt.Printf(
 		"%s %s for %s is connected to %s with %s address\n\n",
@@ -73,7 +76,7 @@ func main() {
 		res.Device.AppVersion,
 		res.Device.Platform,
 		network,
-		addr,
+		addr.Address,
 	)
 
 	msg, err := tonconnect.NewMessage("0QBZ_35Wy144n2GBM93YpcV4KOKcIjDJk8DdX4kyXEEHcbLZ", "100000000")
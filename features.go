@@ -0,0 +1,137 @@
+package tonconnect
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Feature is a capability advertised by the wallet in its device info.
+type Feature interface {
+	FeatureName() string
+}
+
+// SendTransactionFeature is the sendTransaction method support. Legacy is
+// set for wallets that only report the "SendTransaction" string, which
+// implies at most 4 messages.
+type SendTransactionFeature struct {
+	MaxMessages uint64 `json:"maxMessages"`
+	Legacy      bool   `json:"-"`
+}
+
+type SignDataFeature struct {
+	Types []string `json:"types,omitempty"`
+}
+
+// UnknownFeature keeps features this package doesn't know about as is.
+type UnknownFeature struct {
+	Name string
+	Raw  json.RawMessage
+}
+
+type Features []Feature
+
+const legacyMaxMessages = 4
+
+func (SendTransactionFeature) FeatureName() string { return "SendTransaction" }
+
+func (SignDataFeature) FeatureName() string { return "SignData" }
+
+func (f UnknownFeature) FeatureName() string { return f.Name }
+
+func (f SendTransactionFeature) MarshalJSON() ([]byte, error) {
+	if f.Legacy {
+		return json.Marshal(f.FeatureName())
+	}
+
+	type feature SendTransactionFeature
+	return json.Marshal(struct {
+		Name string `json:"name"`
+		feature
+	}{Name: f.FeatureName(), feature: feature(f)})
+}
+
+func (f SignDataFeature) MarshalJSON() ([]byte, error) {
+	type feature SignDataFeature
+	return json.Marshal(struct {
+		Name string `json:"name"`
+		feature
+	}{Name: f.FeatureName(), feature: feature(f)})
+}
+
+func (f UnknownFeature) MarshalJSON() ([]byte, error) {
+	if len(f.Raw) > 0 {
+		return f.Raw, nil
+	}
+
+	return json.Marshal(f.Name)
+}
+
+func (fs *Features) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return fmt.Errorf("tonconnect: failed to unmarshal features: %w", err)
+	}
+
+	*fs = make(Features, 0, len(raws))
+	for _, raw := range raws {
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			if name == "SendTransaction" {
+				*fs = append(*fs, SendTransactionFeature{MaxMessages: legacyMaxMessages, Legacy: true})
+			} else {
+				*fs = append(*fs, UnknownFeature{Name: name, Raw: raw})
+			}
+			continue
+		}
+
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return fmt.Errorf("tonconnect: failed to unmarshal feature: %w", err)
+		}
+
+		switch v.Name {
+		case "SendTransaction":
+			f := SendTransactionFeature{}
+			if err := json.Unmarshal(raw, &f); err != nil {
+				return fmt.Errorf("tonconnect: failed to unmarshal %q feature: %w", v.Name, err)
+			}
+			*fs = append(*fs, f)
+		case "SignData":
+			f := SignDataFeature{}
+			if err := json.Unmarshal(raw, &f); err != nil {
+				return fmt.Errorf("tonconnect: failed to unmarshal %q feature: %w", v.Name, err)
+			}
+			*fs = append(*fs, f)
+		default:
+			*fs = append(*fs, UnknownFeature{Name: v.Name, Raw: raw})
+		}
+	}
+
+	return nil
+}
+
+// SendTransaction returns the sendTransaction feature, preferring the typed
+// form over the legacy string wallets send alongside it for compatibility.
+func (fs Features) SendTransaction() (SendTransactionFeature, bool) {
+	var res SendTransactionFeature
+	found := false
+	for _, f := range fs {
+		if f, ok := f.(SendTransactionFeature); ok && (!found || res.Legacy) {
+			res, found = f, true
+		}
+	}
+
+	return res, found
+}
+
+func (fs Features) SignData() (SignDataFeature, bool) {
+	for _, f := range fs {
+		if f, ok := f.(SignDataFeature); ok {
+			return f, true
+		}
+	}
+
+	return SignDataFeature{}, false
+}
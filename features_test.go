@@ -0,0 +1,112 @@
+package tonconnect
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestFeaturesUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantSend    SendTransactionFeature
+		wantSendOK  bool
+		wantSign    []string
+		wantSignOK  bool
+		wantUnknown []string
+	}{
+		{
+			name:       "legacy string",
+			data:       `["SendTransaction"]`,
+			wantSend:   SendTransactionFeature{MaxMessages: legacyMaxMessages, Legacy: true},
+			wantSendOK: true,
+		},
+		{
+			name:       "typed after legacy",
+			data:       `["SendTransaction", {"name": "SendTransaction", "maxMessages": 255}]`,
+			wantSend:   SendTransactionFeature{MaxMessages: 255},
+			wantSendOK: true,
+		},
+		{
+			name:       "typed before legacy",
+			data:       `[{"name": "SendTransaction", "maxMessages": 16}, "SendTransaction"]`,
+			wantSend:   SendTransactionFeature{MaxMessages: 16},
+			wantSendOK: true,
+		},
+		{
+			name:        "sign data and unknown",
+			data:        `[{"name": "SignData", "types": ["text", "cell"]}, "Subscription", {"name": "SendExtraCurrency", "x": 1}]`,
+			wantSign:    []string{"text", "cell"},
+			wantSignOK:  true,
+			wantUnknown: []string{"Subscription", "SendExtraCurrency"},
+		},
+		{name: "empty", data: `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fs Features
+			if err := json.Unmarshal([]byte(tt.data), &fs); err != nil {
+				t.Fatal(err)
+			}
+
+			send, ok := fs.SendTransaction()
+			if ok != tt.wantSendOK || send != tt.wantSend {
+				t.Errorf("SendTransaction() = %+v, %v, want %+v, %v", send, ok, tt.wantSend, tt.wantSendOK)
+			}
+			sign, ok := fs.SignData()
+			if ok != tt.wantSignOK || !slices.Equal(sign.Types, tt.wantSign) {
+				t.Errorf("SignData() = %+v, %v, want %v, %v", sign, ok, tt.wantSign, tt.wantSignOK)
+			}
+
+			var unknown []string
+			for _, f := range fs {
+				if f, ok := f.(UnknownFeature); ok {
+					unknown = append(unknown, f.FeatureName())
+				}
+			}
+			if !slices.Equal(unknown, tt.wantUnknown) {
+				t.Errorf("unknown features = %v, want %v", unknown, tt.wantUnknown)
+			}
+
+			// Features are encoded back as received.
+			data, err := json.Marshal(fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want, got any
+			json.Unmarshal([]byte(tt.data), &want)
+			json.Unmarshal(data, &got)
+			if ws, gs := mustMarshal(t, want), mustMarshal(t, got); ws != gs {
+				t.Errorf("MarshalJSON() = %s, want %s", gs, ws)
+			}
+		})
+	}
+}
+
+func TestFeaturesUnmarshalJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"name": "SendTransaction"}`,
+		`[1]`,
+		`[{"name": "SendTransaction", "maxMessages": "4"}]`,
+		`[{"name": "SendTransaction", "maxMessages": -1}]`,
+		`[{"name": "SignData", "types": "text"}]`,
+	} {
+		var fs Features
+		if err := json.Unmarshal([]byte(data), &fs); err == nil {
+			t.Errorf("Unmarshal(%s) error = nil", data)
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
@@ -3,6 +3,7 @@ module github.com/cameo-engineering/tonconnect
 go 1.21.5
 
 require (
+	github.com/cenkalti/backoff/v4 v4.2.1
 	github.com/kevinburke/nacl v0.0.0-20210405173606-cd9060f5f776
 	github.com/tmaxmax/go-sse v0.7.0
 	golang.org/x/exp v0.0.0-20231226003508-02704c960a9b
@@ -10,7 +11,6 @@ require (
 )
 
 require (
-	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
 	golang.org/x/crypto v0.17.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 )
@@ -0,0 +1,293 @@
+package tonconnect
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Event is an unsolicited wallet event (e.g. "connect" or "disconnect")
+// delivered to the handler passed to Session.Listen.
+type Event struct {
+	ID      uint64
+	Name    string
+	Payload EventPayload
+}
+
+// Listen keeps a single bridge subscription open until ctx is done. Replies
+// to requests issued while listening are dispatched to the pending calls by
+// their ID, any other wallet event is passed to handler. Events are handed
+// to handler in order on a separate goroutine, so it may issue requests on
+// the session itself. Listen returns nil once the wallet disconnects or the
+// session is disconnected with Disconnect.
+func (s *Session) Listen(ctx context.Context, handler func(Event)) error {
+	if _, bridgeURL := s.peer(); bridgeURL == "" {
+		return fmt.Errorf("tonconnect: session not established")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	if s.listening {
+		s.mu.Unlock()
+		return fmt.Errorf("tonconnect: session is already listening")
+	}
+	s.listening = true
+	s.unlisten = cancel
+	if handler != nil {
+		s.events = &eventQueue{ready: make(chan struct{}, 1)}
+	}
+	events := s.events
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		if events != nil {
+			events.run(handler, done)
+		}
+	}()
+
+	err := s.subscribe(ctx, nil)
+
+	// Requests waiting for their reply on this subscription would hang
+	// otherwise.
+	cause := ErrStreamClosed
+	if err != nil {
+		cause = fmt.Errorf("%w: %w", ErrStreamClosed, err)
+	}
+
+	s.mu.Lock()
+	s.listening = false
+	s.events = nil
+	s.unlisten = nil
+	for id, p := range s.pending {
+		if p.shared {
+			p.fail(cause)
+			delete(s.pending, id)
+		}
+	}
+	s.mu.Unlock()
+
+	close(done)
+	<-delivered
+
+	return err
+}
+
+func (s *Session) subscribe(ctx context.Context, opened func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g, ctx := errgroup.WithContext(ctx)
+	msgs := make(chan bridgeMessage)
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case msg := <-msgs:
+				disconnected := s.dispatch(msg)
+				if err := s.checkpoint(ctx); err != nil {
+					return err
+				}
+				if disconnected {
+					cancel()
+				}
+			}
+		}
+	})
+
+	g.Go(func() error {
+		_, bridgeURL := s.peer()
+		return s.connectToBridge(ctx, bridgeURL, msgs, opened)
+	})
+
+	return g.Wait()
+}
+
+func (s *Session) dispatch(msg bridgeMessage) bool {
+	id, err := msg.Message.ID.Int64()
+	if err == nil {
+		s.observeRequestID(uint64(id))
+	}
+
+	if msg.Message.Event == "" {
+		if err != nil {
+			return false
+		}
+
+		s.mu.Lock()
+		p, ok := s.pending[uint64(id)]
+		delete(s.pending, uint64(id))
+		s.mu.Unlock()
+
+		if ok {
+			p.ch <- msg.Message
+		}
+
+		return false
+	}
+
+	if msg.Message.Event == "disconnect" {
+		s.reset()
+	}
+
+	s.mu.Lock()
+	events := s.events
+	s.mu.Unlock()
+
+	if events != nil {
+		events.push(Event{ID: uint64(id), Name: msg.Message.Event, Payload: msg.Message.Payload})
+	}
+
+	if msg.Message.Event == "disconnect" {
+		if s.onDisconnect != nil {
+			s.onDisconnect(s)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (s *Session) reset() {
+	s.mu.Lock()
+	s.ClientID = nil
+	s.BridgeURL = ""
+	s.Account = nil
+	s.Device = nil
+	for id, p := range s.pending {
+		p.fail(ErrDisconnected)
+		delete(s.pending, id)
+	}
+	// The listener would otherwise keep accepting messages from any sender
+	// now that ClientID is cleared.
+	if s.unlisten != nil {
+		s.unlisten()
+	}
+	s.mu.Unlock()
+}
+
+// eventQueue passes events to the Listen handler without blocking the
+// subscription, which also routes the replies the handler may wait for.
+type eventQueue struct {
+	mu     sync.Mutex
+	events []Event
+	ready  chan struct{}
+}
+
+func (q *eventQueue) push(e Event) {
+	q.mu.Lock()
+	q.events = append(q.events, e)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue) pop() []Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	events := q.events
+	q.events = nil
+
+	return events
+}
+
+// run calls handler for queued events until done is closed, then delivers
+// the remaining ones.
+func (q *eventQueue) run(handler func(Event), done <-chan struct{}) {
+	for {
+		select {
+		case <-q.ready:
+			for _, e := range q.pop() {
+				handler(e)
+			}
+		case <-done:
+			for _, e := range q.pop() {
+				handler(e)
+			}
+			return
+		}
+	}
+}
+
+// pendingRequest waits for the wallet reply to a request. Shared requests
+// get their reply through the Listen subscription.
+type pendingRequest struct {
+	ch     chan walletMessage
+	err    error
+	shared bool
+}
+
+// fail closes the reply channel, err is returned to the waiting request.
+func (p *pendingRequest) fail(err error) {
+	p.err = err
+	close(p.ch)
+}
+
+func (s *Session) request(ctx context.Context, id uint64, req any, topic string, options ...bridgeMessageOption) (walletMessage, error) {
+	p := &pendingRequest{ch: make(chan walletMessage, 1)}
+
+	s.mu.Lock()
+	if s.pending == nil {
+		s.pending = make(map[uint64]*pendingRequest)
+	}
+	p.shared = s.listening
+	s.pending[id] = p
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g, gctx := errgroup.WithContext(ctx)
+
+	// Without an active listener open a subscription for this call only.
+	if !p.shared {
+		g.Go(func() error {
+			return s.subscribe(gctx, nil)
+		})
+	}
+
+	msg, err := s.roundTrip(gctx, p, req, topic, options...)
+	cancel()
+	if gerr := g.Wait(); err != nil && gerr != nil {
+		err = gerr
+	}
+
+	return msg, err
+}
+
+func (s *Session) roundTrip(ctx context.Context, p *pendingRequest, req any, topic string, options ...bridgeMessageOption) (walletMessage, error) {
+	if err := s.sendMessage(ctx, req, topic, options...); err != nil {
+		return walletMessage{}, err
+	}
+	if err := s.checkpoint(ctx); err != nil {
+		return walletMessage{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return walletMessage{}, ctx.Err()
+	case msg, ok := <-p.ch:
+		if !ok {
+			return msg, p.err
+		}
+
+		return msg, nil
+	}
+}
@@ -0,0 +1,249 @@
+package tonconnect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+)
+
+func TestListenFailsPendingRequests(t *testing.T) {
+	var connects atomic.Int32
+	posted := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			if connects.Add(1) > 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			// Drop the stream once the request has been sent.
+			select {
+			case <-posted:
+			case <-r.Context().Done():
+			}
+		case "/message":
+			posted <- struct{}{}
+		}
+	}))
+	defer srv.Close()
+
+	clientID, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSession(WithMaxRetries(2), WithRetryInterval(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ClientID = clientID
+	s.BridgeURL = srv.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.Listen(ctx, nil)
+	}()
+	waitListening(s)
+
+	_, err = s.request(ctx, s.nextRequestID(), struct{}{}, "")
+	if !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("request error = %v, want %v", err, ErrStreamClosed)
+	}
+	if err := <-listenErr; err == nil {
+		t.Fatal("Listen returned nil after the bridge went away")
+	}
+	if n := connects.Load(); n != 3 {
+		t.Fatalf("bridge connections = %d, want 3", n)
+	}
+}
+
+// fakeWallet is a bridge with a single wallet behind it which answers every
+// request with a successful reply. The decrypted requests are sent to
+// requests when it is set.
+type fakeWallet struct {
+	id, key  nacl.Key
+	session  *Session
+	events   chan string
+	srv      *httptest.Server
+	result   string
+	requests chan []byte
+}
+
+func newFakeWallet(t *testing.T, s *Session) *fakeWallet {
+	t.Helper()
+
+	id, key, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &fakeWallet{id: id, key: key, session: s, events: make(chan string, 16), result: "ok"}
+
+	var seq atomic.Uint64
+	w.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			rw.Header().Set("Content-Type", "text/event-stream")
+			rw.WriteHeader(http.StatusOK)
+			rw.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-w.events:
+					data, _ := json.Marshal(map[string]any{
+						"from":    hex.EncodeToString(w.id[:]),
+						"message": box.EasySeal([]byte(event), s.ID, w.key),
+					})
+					fmt.Fprintf(rw, "id: %d\nevent: message\ndata: %s\n\n", seq.Add(1), data)
+					rw.(http.Flusher).Flush()
+				}
+			}
+		case "/message":
+			body, _ := io.ReadAll(r.Body)
+			sealed, err := base64.StdEncoding.DecodeString(string(body))
+			if err != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, err := box.EasyOpen(sealed, s.ID, w.key)
+			if err != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(data, &req)
+			if w.requests != nil {
+				w.requests <- data
+			}
+			w.events <- fmt.Sprintf(`{"id":%q,"result":%q}`, req.ID, w.result)
+		}
+	}))
+	t.Cleanup(w.srv.Close)
+
+	s.ClientID = w.id
+	s.BridgeURL = w.srv.URL
+
+	return w
+}
+
+func TestListenHandlerCanSendRequests(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newFakeWallet(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results := make(chan error, 1)
+	handler := func(e Event) {
+		if e.Name == "bye" {
+			results <- s.Disconnect(ctx)
+			return
+		}
+		id := s.nextRequestID()
+		msg, err := s.request(ctx, id, disconnectRequest{ID: strconv.FormatUint(id, 10), Method: "ping", Params: []any{}}, "")
+		if err == nil && msg.Result != "ok" {
+			err = fmt.Errorf("result = %v, want ok", msg.Result)
+		}
+		results <- err
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.Listen(ctx, handler)
+	}()
+	w.events <- `{"event":"ping","id":"10","payload":{}}`
+
+	select {
+	case err := <-results:
+		if err != nil {
+			t.Fatalf("request from handler error = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("request from handler never got its reply")
+	}
+
+	// Disconnecting from the handler ends Listen.
+	w.events <- `{"event":"bye","id":"11","payload":{}}`
+	if err := <-results; err != nil {
+		t.Fatalf("Disconnect() from handler error = %v", err)
+	}
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Listen() kept running after Disconnect")
+	}
+}
+
+func TestListenReturnsOnDisconnect(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newFakeWallet(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan Event, 1)
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.Listen(ctx, func(e Event) { events <- e })
+	}()
+	w.events <- `{"event":"ping","id":"10","payload":{}}`
+	<-events
+
+	if err := s.Disconnect(ctx); err != nil {
+		t.Fatalf("Disconnect() error = %v", err)
+	}
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Listen() kept running after Disconnect")
+	}
+	if s.ClientID != nil || s.BridgeURL != "" {
+		t.Fatal("session was not reset")
+	}
+}
+
+func waitListening(s *Session) {
+	for {
+		s.mu.Lock()
+		listening := s.listening
+		s.mu.Unlock()
+		if listening {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
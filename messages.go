@@ -13,58 +13,63 @@ type bridgeMessage struct {
 }
 
 type walletMessage struct {
-	ID      json.Number `json:"id,omitempty"`
-	Event   string      `json:"event,omitempty"`
-	Type    string      `json:"type,omitempty"`
-	Result  any         `json:"result,omitempty"`
-	Payload payload     `json:"payload,omitempty"`
-	Error   *struct {
-		Code    uint64 `json:"code"`
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
+	ID      json.Number  `json:"id,omitempty"`
+	Event   string       `json:"event,omitempty"`
+	Type    string       `json:"type,omitempty"`
+	Result  any          `json:"result,omitempty"`
+	Payload EventPayload `json:"payload,omitempty"`
+	Error   *WalletError `json:"error,omitempty"`
 }
 
-type payload struct {
+// WalletError is an error reported by the wallet in a reply.
+type WalletError struct {
+	Code    uint64 `json:"code"`
+	Message string `json:"message"`
+}
+
+// EventPayload is the payload of a wallet event. Code and Message are set for
+// "connect_error", Device and Items for "connect".
+type EventPayload struct {
 	Code    uint64             `json:"code,omitempty"`
 	Message string             `json:"message,omitempty"`
-	Device  deviceInfo         `json:"device,omitempty"`
-	Items   []connectItemReply `json:"items,omitempty"`
+	Device  DeviceInfo         `json:"device,omitempty"`
+	Items   []ConnectItemReply `json:"items,omitempty"`
 }
 
-type deviceInfo struct {
-	Platform           string `json:"platform"`
-	AppName            string `json:"appName"`
-	AppVersion         string `json:"appVersion"`
-	MaxProtocolVersion uint64 `json:"maxProtocolVersion"`
-	Features           []any  `json:"features"`
+type DeviceInfo struct {
+	Platform           string   `json:"platform"`
+	AppName            string   `json:"appName"`
+	AppVersion         string   `json:"appVersion"`
+	MaxProtocolVersion uint64   `json:"maxProtocolVersion"`
+	Features           Features `json:"features"`
 }
 
-type feature struct {
-	Name        string `json:"name"`
-	MaxMessages uint64 `json:"maxMessages,omitempty"`
+// ConnectItemReply is a reply to a single item of the connect request. The
+// fields of TonAddrItem are set for "ton_addr" and Proof for "ton_proof".
+type ConnectItemReply struct {
+	Name string `json:"name"`
+	TonAddrItem
+	Proof TonProofItem `json:"proof,omitempty"`
+	Error *WalletError `json:"error,omitempty"`
 }
 
-type connectItemReply struct {
-	Name            string `json:"name"`
+type TonAddrItem struct {
 	Address         string `json:"address,omitempty"`
 	Network         int64  `json:"network,string,omitempty"`
 	PublicKey       string `json:"publicKey,omitempty"`
 	WalletStateInit []byte `json:"walletStateInit,omitempty"`
-	Proof           proof  `json:"proof,omitempty"`
-	Error           *struct {
-		Code    uint64 `json:"code"`
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
 }
 
-type proof struct {
-	Timestamp uint64 `json:"timestamp"`
-	Domain    struct {
-		LengthBytes uint64 `json:"lengthBytes"`
-		Value       string `json:"value"`
-	} `json:"domain"`
-	Signature []byte `json:"signature"`
-	Payload   string `json:"payload"`
+type TonProofItem struct {
+	Timestamp uint64         `json:"timestamp"`
+	Domain    TonProofDomain `json:"domain"`
+	Signature []byte         `json:"signature"`
+	Payload   string         `json:"payload"`
+}
+
+type TonProofDomain struct {
+	LengthBytes uint64 `json:"lengthBytes"`
+	Value       string `json:"value"`
 }
 
 type signDataResult struct {
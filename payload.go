@@ -0,0 +1,73 @@
+package tonconnect
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProofPayloadIssuer issues ton_proof payloads that carry their own expiry
+// and an HMAC, so they can be validated by any instance sharing the secret
+// without storing nonces.
+type ProofPayloadIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+var ErrInvalidPayload = errors.New("tonconnect: invalid ton_proof payload")
+
+const (
+	payloadNonceSize = 16
+	payloadMACSize   = 16
+	payloadSize      = payloadNonceSize + 8 + payloadMACSize
+)
+
+func NewProofPayloadIssuer(secret []byte, ttl time.Duration) *ProofPayloadIssuer {
+	return &ProofPayloadIssuer{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Issue returns a hex encoded payload made of a random nonce, the expiry
+// time and a truncated HMAC-SHA256 of both.
+func (i *ProofPayloadIssuer) Issue() (string, error) {
+	data := make([]byte, payloadNonceSize, payloadSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("tonconnect: failed to generate payload nonce: %w", err)
+	}
+
+	data = binary.BigEndian.AppendUint64(data, uint64(i.now().Add(i.ttl).Unix()))
+	data = append(data, i.mac(data)...)
+
+	return hex.EncodeToString(data), nil
+}
+
+func (i *ProofPayloadIssuer) Validate(payload string) error {
+	data, err := hex.DecodeString(payload)
+	if err != nil || len(data) != payloadSize {
+		return fmt.Errorf("%w: malformed payload", ErrInvalidPayload)
+	}
+
+	msg, mac := data[:payloadSize-payloadMACSize], data[payloadSize-payloadMACSize:]
+	if !hmac.Equal(mac, i.mac(msg)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidPayload)
+	}
+
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(msg[payloadNonceSize:])), 0)
+	if i.now().After(expiry) {
+		return fmt.Errorf("%w: payload has expired", ErrInvalidPayload)
+	}
+
+	return nil
+}
+
+func (i *ProofPayloadIssuer) mac(msg []byte) []byte {
+	h := hmac.New(sha256.New, i.secret)
+	h.Write(msg)
+
+	return h.Sum(nil)[:payloadMACSize]
+}
@@ -0,0 +1,56 @@
+package tonconnect
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProofPayloadIssuer(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	issuer := NewProofPayloadIssuer([]byte("secret"), time.Minute)
+	issuer.now = func() time.Time { return now }
+
+	payload, err := issuer.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) != 2*payloadSize {
+		t.Fatalf("payload length = %d, want %d", len(payload), 2*payloadSize)
+	}
+
+	other := NewProofPayloadIssuer([]byte("other"), time.Minute)
+	other.now = issuer.now
+
+	expired := NewProofPayloadIssuer([]byte("secret"), time.Minute)
+	expired.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+	flipped := []byte(payload)
+	flipped[0] ^= 1
+
+	tests := []struct {
+		name    string
+		issuer  *ProofPayloadIssuer
+		payload string
+		wantErr bool
+	}{
+		{name: "valid", issuer: issuer, payload: payload},
+		{name: "other secret", issuer: other, payload: payload, wantErr: true},
+		{name: "expired", issuer: expired, payload: payload, wantErr: true},
+		{name: "tampered", issuer: issuer, payload: string(flipped), wantErr: true},
+		{name: "truncated", issuer: issuer, payload: payload[:len(payload)-2], wantErr: true},
+		{name: "not hex", issuer: issuer, payload: "zz" + payload[2:], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.issuer.Validate(tt.payload)
+			if tt.wantErr && !errors.Is(err, ErrInvalidPayload) {
+				t.Fatalf("Validate() error = %v, want %v", err, ErrInvalidPayload)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+		})
+	}
+}
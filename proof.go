@@ -0,0 +1,190 @@
+package tonconnect
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type proofOptions struct {
+	Domains  []string
+	MaxAge   time.Duration
+	Payload  string
+	Issuer   *ProofPayloadIssuer
+	Resolver PublicKeyResolver
+	Now      func() time.Time
+}
+
+// PublicKeyResolver looks up the public key of a deployed wallet, e.g. with
+// the get_public_key get-method of its contract.
+type PublicKeyResolver func(address Address) (ed25519.PublicKey, error)
+
+// ProofOption configures VerifyProof. It is exported so that other packages,
+// such as auth, can pass the options through.
+type ProofOption = func(*proofOptions)
+
+var ErrInvalidProof = errors.New("tonconnect: invalid ton_proof")
+
+const (
+	tonProofPrefix   = "ton-proof-item-v2/"
+	tonConnectPrefix = "ton-connect"
+)
+
+// VerifyProof checks the ton_proof returned by the wallet in res. The wallet
+// public key is taken from the state init, which is tied to the address by
+// its hash. Deployed wallets may omit the state init, their proofs are only
+// accepted with WithPublicKeyResolver: the public key reported by the wallet
+// alone proves nothing about the address. At least one allowed domain must be
+// set with WithAllowedDomains.
+func VerifyProof(res *ConnectResponse, options ...ProofOption) error {
+	opts := &proofOptions{MaxAge: 15 * time.Minute, Now: time.Now}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	addr, ok := res.Address()
+	if !ok {
+		return fmt.Errorf("%w: %q item is missing", ErrInvalidProof, "ton_addr")
+	}
+	tp, ok := res.Proof()
+	if !ok {
+		return fmt.Errorf("%w: %q item is missing", ErrInvalidProof, "ton_proof")
+	}
+
+	reported, err := hex.DecodeString(addr.PublicKey)
+	if err != nil || (len(reported) != 0 && len(reported) != ed25519.PublicKeySize) {
+		return fmt.Errorf("%w: wallet public key is malformed", ErrInvalidProof)
+	}
+
+	var pubkey ed25519.PublicKey
+	switch {
+	case len(addr.WalletStateInit) > 0:
+		wsi, err := ParseWalletStateInit(addr.Address, addr.WalletStateInit)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidProof, err)
+		}
+		pubkey = wsi.PublicKey
+	case opts.Resolver != nil:
+		a, err := ParseAddress(addr.Address)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidProof, err)
+		}
+		pubkey, err = opts.Resolver(a)
+		if err != nil {
+			return fmt.Errorf("%w: failed to resolve wallet public key: %w", ErrInvalidProof, err)
+		}
+		if len(pubkey) != ed25519.PublicKeySize {
+			return fmt.Errorf("%w: resolved wallet public key is malformed", ErrInvalidProof)
+		}
+	default:
+		return fmt.Errorf("%w: wallet state init is missing", ErrInvalidProof)
+	}
+
+	if len(reported) != 0 && !bytes.Equal(reported, pubkey) {
+		return fmt.Errorf("%w: wallet public key does not match the address", ErrInvalidProof)
+	}
+
+	return verifyProof(addr.Address, pubkey, tp, opts)
+}
+
+func verifyProof(address string, pubkey ed25519.PublicKey, p TonProofItem, opts *proofOptions) error {
+	if p.Domain.LengthBytes != uint64(len(p.Domain.Value)) {
+		return fmt.Errorf("%w: domain length mismatch", ErrInvalidProof)
+	}
+	if len(opts.Domains) == 0 {
+		return fmt.Errorf("%w: no allowed domains configured", ErrInvalidProof)
+	}
+	if !slices.Contains(opts.Domains, p.Domain.Value) {
+		return fmt.Errorf("%w: domain %q is not allowed", ErrInvalidProof, p.Domain.Value)
+	}
+
+	if opts.MaxAge > 0 {
+		ts := time.Unix(int64(p.Timestamp), 0)
+		now := opts.Now()
+		if now.Sub(ts) > opts.MaxAge {
+			return fmt.Errorf("%w: proof has expired", ErrInvalidProof)
+		}
+		if ts.Sub(now) > time.Minute {
+			return fmt.Errorf("%w: proof timestamp is in the future", ErrInvalidProof)
+		}
+	}
+
+	if opts.Payload != "" && p.Payload != opts.Payload {
+		return fmt.Errorf("%w: payload mismatch", ErrInvalidProof)
+	}
+	if opts.Issuer != nil {
+		if err := opts.Issuer.Validate(p.Payload); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidProof, err)
+		}
+	}
+
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidProof, err)
+	}
+
+	msg := make([]byte, 0, len(tonProofPrefix)+4+32+4+len(p.Domain.Value)+8+len(p.Payload))
+	msg = append(msg, tonProofPrefix...)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(addr.Workchain))
+	msg = append(msg, addr.Hash[:]...)
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(p.Domain.LengthBytes))
+	msg = append(msg, p.Domain.Value...)
+	msg = binary.LittleEndian.AppendUint64(msg, p.Timestamp)
+	msg = append(msg, p.Payload...)
+	msgHash := sha256.Sum256(msg)
+
+	full := make([]byte, 0, 2+len(tonConnectPrefix)+len(msgHash))
+	full = append(full, 0xff, 0xff)
+	full = append(full, tonConnectPrefix...)
+	full = append(full, msgHash[:]...)
+	fullHash := sha256.Sum256(full)
+
+	if !ed25519.Verify(pubkey, fullHash[:], p.Signature) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidProof)
+	}
+
+	return nil
+}
+
+// WithAllowedDomains sets the app domains proofs may be signed for, without
+// the scheme, e.g. "example.com". It is required by VerifyProof.
+func WithAllowedDomains(domains ...string) ProofOption {
+	return func(opts *proofOptions) {
+		opts.Domains = append(opts.Domains, domains...)
+	}
+}
+
+// WithMaxAge sets how old a proof may be. Zero disables the check.
+func WithMaxAge(age time.Duration) ProofOption {
+	return func(opts *proofOptions) {
+		opts.MaxAge = age
+	}
+}
+
+// WithPublicKeyResolver accepts proofs of deployed wallets that send no
+// state init, their public key is looked up with resolve.
+func WithPublicKeyResolver(resolve PublicKeyResolver) ProofOption {
+	return func(opts *proofOptions) {
+		opts.Resolver = resolve
+	}
+}
+
+func WithExpectedPayload(payload string) ProofOption {
+	return func(opts *proofOptions) {
+		opts.Payload = payload
+	}
+}
+
+// WithPayloadIssuer checks that the proof payload was issued by issuer and
+// has not expired yet.
+func WithPayloadIssuer(issuer *ProofPayloadIssuer) ProofOption {
+	return func(opts *proofOptions) {
+		opts.Issuer = issuer
+	}
+}
@@ -0,0 +1,193 @@
+package tonconnect
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Wallet generated by tonutils-go from the ed25519 seed 0x01, 0x02, ... 0x20.
+const (
+	testPublicKey  = "79b5562e8fe654f94078b112e8a98ba7901f853ae695bed7e0e3910bad049664"
+	testV4R2Addr   = "0:e71f2b5f35e5cd52f7dd471e359e5b15a93fc3b88fd6bc5cccacd9d5afb9fc85"
+	testV4R2BoCHex = "b5ee9c72410216010003040002013401020114ff00f4a413f4bcf2c80b0300510000000029a9a31779b5562e8fe654f94078b112e8a98ba7901f853ae695bed7e0e3910bad049664400201200405020148060704f8f28308d71820d31fd31fd31f02f823bbf264ed44d0d31fd31fd3fff404d15143baf2a15151baf2a205f901541064f910f2a3f80024a4c8cb1f5240cb1f5230cbff5210f400c9ed54f80f01d30721c0009f6c519320d74a96d307d402fb00e830e021c001e30021c002e30001c0039130e30d03a4c8cb1f12cb1fcbff08090a0b02e6d001d0d3032171b0925f04e022d749c120925f04e002d31f218210706c7567bd22821064737472bdb0925f05e003fa403020fa4401c8ca07cbffc9d0ed44d0810140d721f404305c810108f40a6fa131b3925f07e005d33fc8258210706c7567ba923830e30d03821064737472ba925f06e30d0c0d0201200e0f006ed207fa00d4d422f90005c8ca0715cbffc9d077748018c8cb05cb0222cf165005fa0214cb6b12ccccc973fb00c84014810108f451f2a7020070810108d718fa00d33fc8542047810108f451f2a782106e6f746570748018c8cb05cb025006cf165004fa0214cb6a12cb1fcb3fc973fb0002006c810108d718fa00d33f305224810108f459f2a782106473747270748018c8cb05cb025005cf165003fa0213cb6acb1f12cb3fc973fb00000af400c9ed54007801fa00f40430f8276f2230500aa121bef2e0508210706c7567831eb17080185004cb0526cf1658fa0219f400cb6917cb1f5260cb3f20c98040fb0006008a5004810108f45930ed44d0810140d720c801cf16f400c9ed540172b08e23821064737472831eb17080185005cb055003cf1623fa0213cb6acb1fcb3fc98040fb00925f03e202012010110059bd242b6f6a2684080a06b90fa0218470d4080847a4937d29910ce6903e9ff9837812801b7810148987159f318402015812130011b8c97ed44d0d70b1f8003db29dfb513420405035c87d010c00b23281f2fff274006040423d029be84c6002012014150019adce76a26840206b90eb85ffc00019af1df6a26840106b90eb858fc0f7fd51ce"
+)
+
+func testWalletKey() ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// signTestProof signs a ton_proof as described in the TON Connect
+// specification:
+//
+//	message = "ton-proof-item-v2/" ++ workchain (BE) ++ hash ++ domain length (LE)
+//	          ++ domain ++ timestamp (LE) ++ payload
+//	signature = ed25519(sha256(0xffff ++ "ton-connect" ++ sha256(message)))
+func signTestProof(key ed25519.PrivateKey, workchain int32, hash []byte, domain string, ts uint64, payload string) []byte {
+	var msg []byte
+	msg = append(msg, "ton-proof-item-v2/"...)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(workchain))
+	msg = append(msg, hash...)
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(len(domain)))
+	msg = append(msg, domain...)
+	msg = binary.LittleEndian.AppendUint64(msg, ts)
+	msg = append(msg, payload...)
+	h := sha256.Sum256(msg)
+
+	full := append([]byte{0xff, 0xff}, "ton-connect"...)
+	full = append(full, h[:]...)
+	fh := sha256.Sum256(full)
+
+	return ed25519.Sign(key, fh[:])
+}
+
+func TestVerifyProof(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	victim := testWalletKey()
+	attacker := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	attackerPub := hex.EncodeToString(attacker.Public().(ed25519.PublicKey))
+	hash := mustDecodeHex(t, testV4R2Addr[2:])
+	stateInit := mustDecodeHex(t, testV4R2BoCHex)
+
+	response := func(key ed25519.PrivateKey, pubkey string, stateInit []byte, domain string, ts time.Time) *ConnectResponse {
+		return &ConnectResponse{Items: []ConnectItemReply{
+			{
+				Name: "ton_addr",
+				TonAddrItem: TonAddrItem{
+					Address:         testV4R2Addr,
+					Network:         -239,
+					PublicKey:       pubkey,
+					WalletStateInit: stateInit,
+				},
+			},
+			{
+				Name: "ton_proof",
+				Proof: TonProofItem{
+					Timestamp: uint64(ts.Unix()),
+					Domain:    TonProofDomain{LengthBytes: uint64(len(domain)), Value: domain},
+					Signature: signTestProof(key, 0, hash, domain, uint64(ts.Unix()), "payload"),
+					Payload:   "payload",
+				},
+			},
+		}}
+	}
+	resolveTo := func(key ed25519.PrivateKey) ProofOption {
+		return WithPublicKeyResolver(func(Address) (ed25519.PublicKey, error) {
+			return key.Public().(ed25519.PublicKey), nil
+		})
+	}
+
+	tests := []struct {
+		name    string
+		res     *ConnectResponse
+		options []ProofOption
+		wantErr bool
+	}{
+		{
+			name: "valid with state init",
+			res:  response(victim, testPublicKey, stateInit, "example.com", now),
+		},
+		{
+			name: "valid without reported public key",
+			res:  response(victim, "", stateInit, "example.com", now),
+		},
+		{
+			name:    "valid with resolver",
+			res:     response(victim, testPublicKey, nil, "example.com", now),
+			options: []ProofOption{resolveTo(victim)},
+		},
+		{
+			name:    "forged with reported key and no state init",
+			res:     response(attacker, attackerPub, nil, "example.com", now),
+			wantErr: true,
+		},
+		{
+			name:    "forged key rejected by resolver",
+			res:     response(attacker, attackerPub, nil, "example.com", now),
+			options: []ProofOption{resolveTo(victim)},
+			wantErr: true,
+		},
+		{
+			name:    "reported key does not match state init",
+			res:     response(attacker, attackerPub, stateInit, "example.com", now),
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key",
+			res:     response(attacker, "", stateInit, "example.com", now),
+			wantErr: true,
+		},
+		{
+			name:    "wrong domain",
+			res:     response(victim, testPublicKey, stateInit, "evil.example", now),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			res:     response(victim, testPublicKey, stateInit, "example.com", now.Add(-time.Hour)),
+			wantErr: true,
+		},
+		{
+			name:    "from the future",
+			res:     response(victim, testPublicKey, stateInit, "example.com", now.Add(time.Hour)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]ProofOption{
+				WithAllowedDomains("example.com"),
+				func(opts *proofOptions) { opts.Now = func() time.Time { return now } },
+			}, tt.options...)
+
+			err := VerifyProof(tt.res, options...)
+			if tt.wantErr && !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("VerifyProof() error = %v, want %v", err, ErrInvalidProof)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("VerifyProof() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyProofRequiresAllowedDomains(t *testing.T) {
+	key := testWalletKey()
+	hash := mustDecodeHex(t, testV4R2Addr[2:])
+	ts := uint64(time.Now().Unix())
+	res := &ConnectResponse{Items: []ConnectItemReply{
+		{Name: "ton_addr", TonAddrItem: TonAddrItem{Address: testV4R2Addr, WalletStateInit: mustDecodeHex(t, testV4R2BoCHex)}},
+		{Name: "ton_proof", Proof: TonProofItem{
+			Timestamp: ts,
+			Domain:    TonProofDomain{LengthBytes: 11, Value: "example.com"},
+			Signature: signTestProof(key, 0, hash, "example.com", ts, ""),
+		}},
+	}}
+
+	if err := VerifyProof(res); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("VerifyProof() error = %v, want %v", err, ErrInvalidProof)
+	}
+	if err := VerifyProof(res, WithAllowedDomains("example.com")); err != nil {
+		t.Fatalf("VerifyProof() error = %v", err)
+	}
+}
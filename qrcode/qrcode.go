@@ -0,0 +1,464 @@
+// Package qrcode encodes TON Connect links as QR codes and renders them to
+// PNG and SVG images.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level is the error correction level, the share of the code that may be
+// damaged or covered while it is still readable.
+type Level int
+
+const (
+	Low      Level = iota // 7%
+	Medium                // 15%
+	Quartile              // 25%
+	High                  // 30%
+)
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+var ErrTooLong = errors.New("qrcode: content is too long")
+
+// QRCode is a square matrix of dark and light modules.
+type QRCode struct {
+	Version int
+	Level   Level
+	Mask    int
+
+	size       int
+	modules    []bool
+	isFunction []bool
+}
+
+// Encode encodes content in byte mode using the smallest version that fits
+// it at the given error correction level.
+func Encode(content string, level Level) (*QRCode, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("qrcode: invalid error correction level %d", level)
+	}
+
+	data := []byte(content)
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if segmentBits(version, len(data)) <= dataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLong, len(data))
+	}
+
+	q := &QRCode{Version: version, Level: level, size: version*4 + 17}
+	q.modules = make([]bool, q.size*q.size)
+	q.isFunction = make([]bool, q.size*q.size)
+
+	q.drawFunctionPatterns()
+	q.drawCodewords(addErrorCorrection(version, level, encodeData(version, level, data)))
+
+	q.Mask = -1
+	minPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); q.Mask == -1 || p < minPenalty {
+			q.Mask, minPenalty = mask, p
+		}
+		// Masking is a XOR, applying it again undoes it.
+		q.applyMask(mask)
+	}
+	q.applyMask(q.Mask)
+	q.drawFormatBits(q.Mask)
+
+	return q, nil
+}
+
+// Size returns the width of the code in modules, without the quiet zone.
+func (q *QRCode) Size() int {
+	return q.size
+}
+
+// Dark reports whether the module at column x and row y is dark. Modules
+// outside of the code are light.
+func (q *QRCode) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.size || y >= q.size {
+		return false
+	}
+
+	return q.modules[y*q.size+x]
+}
+
+func (q *QRCode) set(x, y int, dark bool) {
+	q.modules[y*q.size+x] = dark
+	q.isFunction[y*q.size+x] = true
+}
+
+// segmentBits is the length of a byte mode segment with n bytes.
+func segmentBits(version, n int) int {
+	countBits := 8
+	if version > 9 {
+		countBits = 16
+	}
+	if n >= 1<<countBits {
+		return 1 << 30
+	}
+
+	return 4 + countBits + n*8
+}
+
+// rawDataModules is the number of modules available for data and error
+// correction codewords, i.e. not taken by function patterns.
+func rawDataModules(version int) int {
+	res := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		res -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			res -= 36
+		}
+	}
+
+	return res
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+func encodeData(version int, level Level, data []byte) []byte {
+	var bb bitBuffer
+	countBits := 8
+	if version > 9 {
+		countBits = 16
+	}
+	bb.append(0b0100, 4)
+	bb.append(uint32(len(data)), countBits)
+	for _, b := range data {
+		bb.append(uint32(b), 8)
+	}
+
+	capacity := dataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := uint32(0xec); len(bb) < capacity; pad ^= 0xec ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	res := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			res[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	return res
+}
+
+// addErrorCorrection splits data into blocks, appends error correction
+// codewords to each of them and interleaves the blocks.
+func addErrorCorrection(version int, level Level, data []byte) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	raw := rawDataModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := make([]byte, 0, shortLen+1)
+		block = append(block, data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			// Keep the blocks aligned, the padding is skipped below.
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	res := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				res = append(res, block[i])
+			}
+		}
+	}
+
+	return res
+}
+
+func (q *QRCode) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	q.drawFinderPattern(3, 3)
+	q.drawFinderPattern(q.size-4, 3)
+	q.drawFinderPattern(3, q.size-4)
+
+	pos := alignmentPositions(q.Version)
+	last := len(pos) - 1
+	for i, x := range pos {
+		for j, y := range pos {
+			// Skip the corners taken by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the format area, the real bits are drawn after masking.
+	q.drawFormatBits(0)
+	q.drawVersionBits()
+}
+
+func (q *QRCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= q.size || yy >= q.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (q *QRCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	res := make([]int, numAlign)
+	res[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		res[i] = pos
+	}
+
+	return res
+}
+
+func (q *QRCode) drawFormatBits(mask int) {
+	data := formatLevelBits[q.Level]<<3 | uint32(mask)
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	// Around the top left finder pattern.
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	// Next to the other two finder patterns.
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+func (q *QRCode) drawVersionBits() {
+	if q.Version < 7 {
+		return
+	}
+
+	rem := uint32(q.Version)
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := uint32(q.Version)<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := q.size-11+i%3, i/3
+		q.set(a, b, dark)
+		q.set(b, a, dark)
+	}
+}
+
+// drawCodewords places data in the zigzag order: two columns at a time from
+// the right, alternating upwards and downwards.
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		// Skip the vertical timing pattern.
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if q.isFunction[y*q.size+x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y*q.size+x] = data[i/8]>>(7-i%8)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y*q.size+x] {
+				q.modules[y*q.size+x] = !q.modules[y*q.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the module layout with the rules of ISO/IEC 18004 7.8.3,
+// the mask with the lowest score is used.
+func (q *QRCode) penalty() int {
+	res := 0
+	line := make([]bool, q.size)
+	for y := 0; y < q.size; y++ {
+		for x := range line {
+			line[x] = q.Dark(x, y)
+		}
+		res += linePenalty(line)
+	}
+	for x := 0; x < q.size; x++ {
+		for y := range line {
+			line[y] = q.Dark(x, y)
+		}
+		res += linePenalty(line)
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			c := q.Dark(x, y)
+			if c {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size && c == q.Dark(x+1, y) && c == q.Dark(x, y+1) && c == q.Dark(x+1, y+1) {
+				res += 3
+			}
+		}
+	}
+
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	res += k * 10
+
+	return res
+}
+
+var (
+	finderLeft  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderRight = []bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+func linePenalty(line []bool) int {
+	res := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			res += 3 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+len(finderLeft) <= len(line); i++ {
+		if matches(line[i:], finderLeft) {
+			res += 40
+		}
+		if matches(line[i:], finderRight) {
+			res += 40
+		}
+	}
+
+	return res
+}
+
+func matches(line, pattern []bool) bool {
+	for i, v := range pattern {
+		if line[i] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, v>>i&1 != 0)
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,191 @@
+package qrcode
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+const testLink = "tc://?v=2&id=7ae3b6b8b9a40cdbb7e1d0d7f96c1ec0c5e16c0d6ad3e1b1d1b6c4b1a9e1f2a3&r=%7B%22manifestUrl%22%3A%22https%3A%2F%2Fcameo.engineering%2Ftonconnect-manifest.json%22%2C%22items%22%3A%5B%7B%22name%22%3A%22ton_addr%22%7D%5D%7D&ret=none"
+
+var levelNames = map[Level]string{Low: "low", Medium: "medium", Quartile: "quartile", High: "high"}
+
+// The golden matrices were checked to decode to testLink with an independent
+// QR code reader.
+func TestEncodeGolden(t *testing.T) {
+	for level, name := range levelNames {
+		t.Run(name, func(t *testing.T) {
+			q, err := Encode(testLink, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := matrixString(q)
+			path := filepath.Join("testdata", name+".txt")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("Encode(%q, %s) differs from %s:\n%s", testLink, name, path, got)
+			}
+		})
+	}
+}
+
+func TestEncodeFormatInfo(t *testing.T) {
+	for level, name := range levelNames {
+		for _, content := range []string{"ton", testLink, strings.Repeat("ton", 200)} {
+			q, err := Encode(content, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotLevel, gotMask := readFormatInfo(t, q)
+			if gotLevel != level || gotMask != q.Mask {
+				t.Errorf("%s, %d bytes: format info = %s mask %d, want %s mask %d", name, len(content), levelNames[gotLevel], gotMask, name, q.Mask)
+			}
+			if q.Version >= 7 {
+				if v := readVersionInfo(q); v != q.Version {
+					t.Errorf("%s, %d bytes: version info = %d, want %d", name, len(content), v, q.Version)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	tests := []struct {
+		n       int
+		level   Level
+		want    int
+		wantErr error
+	}{
+		{n: 17, level: Low, want: 1},
+		{n: 18, level: Low, want: 2},
+		{n: 7, level: High, want: 1},
+		{n: 8, level: High, want: 2},
+		// The character count takes 16 bits instead of 8 from version 10.
+		{n: 230, level: Low, want: 9},
+		{n: 231, level: Low, want: 10},
+		{n: 98, level: High, want: 9},
+		{n: 99, level: High, want: 10},
+		{n: 2953, level: Low, want: 40},
+		{n: 2954, level: Low, wantErr: ErrTooLong},
+		{n: 2331, level: Medium, want: 40},
+		{n: 2332, level: Medium, wantErr: ErrTooLong},
+		{n: 1663, level: Quartile, want: 40},
+		{n: 1664, level: Quartile, wantErr: ErrTooLong},
+		{n: 1273, level: High, want: 40},
+		{n: 1274, level: High, wantErr: ErrTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d bytes", levelNames[tt.level], tt.n), func(t *testing.T) {
+			q, err := Encode(strings.Repeat("a", tt.n), tt.level)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Encode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if q.Version != tt.want || q.Size() != tt.want*4+17 {
+				t.Fatalf("Encode() version = %d, size = %d, want %d, %d", q.Version, q.Size(), tt.want, tt.want*4+17)
+			}
+		})
+	}
+}
+
+func TestEncodeInvalidLevel(t *testing.T) {
+	if _, err := Encode("ton", High+1); err == nil {
+		t.Fatal("Encode() with an invalid level error = nil")
+	}
+}
+
+func matrixString(q *QRCode) string {
+	var sb strings.Builder
+	for y := 0; y < q.Size(); y++ {
+		for x := 0; x < q.Size(); x++ {
+			if q.Dark(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// readFormatInfo decodes both copies of the format information, correcting
+// up to three bit errors as a reader would.
+func readFormatInfo(t *testing.T, q *QRCode) (Level, int) {
+	t.Helper()
+
+	var first, second uint32
+	// Bit 14 is read first, see drawFormatBits for the layout.
+	for i := 0; i <= 5; i++ {
+		first = setBit(first, i, q.Dark(8, i))
+	}
+	first = setBit(first, 6, q.Dark(8, 7))
+	first = setBit(first, 7, q.Dark(8, 8))
+	first = setBit(first, 8, q.Dark(7, 8))
+	for i := 9; i < 15; i++ {
+		first = setBit(first, i, q.Dark(14-i, 8))
+	}
+	for i := 0; i < 8; i++ {
+		second = setBit(second, i, q.Dark(q.Size()-1-i, 8))
+	}
+	for i := 8; i < 15; i++ {
+		second = setBit(second, i, q.Dark(8, q.Size()-15+i))
+	}
+	if first != second {
+		t.Fatalf("format info copies differ: %015b, %015b", first, second)
+	}
+
+	for level, levelBits := range formatLevelBits {
+		for mask := 0; mask < 8; mask++ {
+			data := levelBits<<3 | uint32(mask)
+			rem := data
+			for i := 0; i < 10; i++ {
+				rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+			}
+			if bits.OnesCount32((data<<10|rem)^0x5412^first) <= 3 {
+				return Level(level), mask
+			}
+		}
+	}
+	t.Fatalf("format info %015b is not a valid code word", first)
+
+	return 0, 0
+}
+
+func readVersionInfo(q *QRCode) int {
+	var v uint32
+	for i := 0; i < 18; i++ {
+		v = setBit(v, i, q.Dark(q.Size()-11+i%3, i/3))
+	}
+
+	return int(v >> 12)
+}
+
+func setBit(v uint32, i int, dark bool) uint32 {
+	if dark {
+		v |= 1 << i
+	}
+
+	return v
+}
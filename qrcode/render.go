@@ -0,0 +1,189 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+type renderOptions struct {
+	Level     Level
+	LevelSet  bool
+	Size      int
+	QuietZone int
+	Logo      image.Image
+	Inverted  bool
+}
+
+type renderOpt = func(*renderOptions)
+
+const (
+	defaultSize      = 256
+	defaultQuietZone = 4
+	// logoRatio is the logo width relative to the code. A fifth of the width
+	// covers 4% of the modules, well within what High level recovers.
+	logoRatio = 5
+)
+
+// PNG renders content as a PNG image of a QR code.
+func PNG(content string, options ...renderOpt) ([]byte, error) {
+	q, opts, err := encode(content, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, q.image(opts)); err != nil {
+		return nil, fmt.Errorf("qrcode: failed to encode PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders content as an SVG image of a QR code.
+func SVG(content string, options ...renderOpt) ([]byte, error) {
+	q, opts, err := encode(content, options)
+	if err != nil {
+		return nil, err
+	}
+
+	width := q.size + 2*opts.QuietZone
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, opts.Size, opts.Size, width, width)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, width, width)
+
+	var path strings.Builder
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+opts.QuietZone, y+opts.QuietZone)
+			}
+		}
+	}
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000"/>`, path.String())
+
+	if opts.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, opts.Logo); err != nil {
+			return nil, fmt.Errorf("qrcode: failed to encode logo: %w", err)
+		}
+
+		// Logo geometry in modules, with a light margin of one module.
+		n := q.size / logoRatio
+		pos := opts.QuietZone + (q.size-n)/2
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="#fff"/>`, pos-1, pos-1, n+2, n+2)
+		fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`, pos, pos, n, n, base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func encode(content string, options []renderOpt) (*QRCode, *renderOptions, error) {
+	opts := &renderOptions{Level: Medium, Size: defaultSize, QuietZone: defaultQuietZone}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.Logo != nil && !opts.LevelSet {
+		opts.Level = High
+	}
+
+	q, err := Encode(content, opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return q, opts, nil
+}
+
+// image draws the code centered in an image of opts.Size pixels. The image
+// is larger when the size is too small to draw every module with at least
+// one pixel.
+func (q *QRCode) image(opts *renderOptions) image.Image {
+	width := q.size + 2*opts.QuietZone
+	scale := max(1, opts.Size/width)
+	size := max(opts.Size, width*scale)
+	offset := (size - q.size*scale) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.Dark(x, y) {
+				r := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale).Add(image.Pt(offset, offset))
+				draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		n := q.size / logoRatio * scale
+		pos := (size - n) / 2
+		draw.Draw(img, image.Rect(pos-scale, pos-scale, pos+n+scale, pos+n+scale), image.White, image.Point{}, draw.Src)
+		drawScaled(img, image.Rect(pos, pos, pos+n, pos+n), opts.Logo)
+	}
+
+	return img
+}
+
+// drawScaled draws src into r of dst with nearest neighbour scaling.
+func drawScaled(dst draw.Image, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() || r.Empty() {
+		return
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := sb.Min.Y + (y-r.Min.Y)*sb.Dy()/r.Dy()
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := sb.Min.X + (x-r.Min.X)*sb.Dx()/r.Dx()
+			c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+			if c.A == 0xff {
+				dst.Set(x, y, c)
+				continue
+			}
+			// Blend translucent logo pixels over the light background.
+			a := uint32(c.A)
+			blend := func(v uint8) uint8 { return uint8((uint32(v)*a + 0xff*(0xff-a)) / 0xff) }
+			dst.Set(x, y, color.RGBA{R: blend(c.R), G: blend(c.G), B: blend(c.B), A: 0xff})
+		}
+	}
+}
+
+// WithLevel sets the error correction level, Medium by default or High when
+// a logo is drawn.
+func WithLevel(level Level) renderOpt {
+	return func(opts *renderOptions) {
+		opts.Level = level
+		opts.LevelSet = true
+	}
+}
+
+// WithSize sets the width and height of the image in pixels, 256 by default.
+func WithSize(size int) renderOpt {
+	return func(opts *renderOptions) {
+		opts.Size = size
+	}
+}
+
+// WithQuietZone sets the width of the light border in modules, 4 by default
+// as required by the standard.
+func WithQuietZone(modules int) renderOpt {
+	return func(opts *renderOptions) {
+		opts.QuietZone = modules
+	}
+}
+
+// WithLogo draws logo in the center of the code, e.g. the icon of the wallet
+// the link is for.
+func WithLogo(logo image.Image) renderOpt {
+	return func(opts *renderOptions) {
+		opts.Logo = logo
+	}
+}
@@ -0,0 +1,134 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+func TestEncodeLevelWithLogo(t *testing.T) {
+	logo := testLogo()
+	tests := []struct {
+		name    string
+		options []renderOpt
+		want    Level
+	}{
+		{name: "default", want: Medium},
+		{name: "logo", options: []renderOpt{WithLogo(logo)}, want: High},
+		{name: "logo with level", options: []renderOpt{WithLevel(Quartile), WithLogo(logo)}, want: Quartile},
+		{name: "level", options: []renderOpt{WithLevel(Low)}, want: Low},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := encode(testLink, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Level != tt.want {
+				t.Fatalf("level = %s, want %s", levelNames[q.Level], levelNames[tt.want])
+			}
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	q, err := Encode(testLink, Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	width := q.Size() + 2*defaultQuietZone
+
+	tests := []struct {
+		name    string
+		options []renderOpt
+		want    int
+	}{
+		{name: "default", want: defaultSize},
+		{name: "size", options: []renderOpt{WithSize(500)}, want: 500},
+		// Every module takes at least one pixel.
+		{name: "too small", options: []renderOpt{WithSize(10)}, want: width},
+		{name: "logo", options: []renderOpt{WithSize(300), WithLogo(testLogo())}, want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := PNG(testLink, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.want || b.Dy() != tt.want {
+				t.Fatalf("PNG() size = %v, want %dx%d", b.Size(), tt.want, tt.want)
+			}
+		})
+	}
+
+	// The quiet zone is light and the top left finder pattern dark.
+	data, err := PNG(testLink, WithSize(width*4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(bytes.NewReader(data))
+	if isDark(img.At(0, 0)) || !isDark(img.At(defaultQuietZone*4, defaultQuietZone*4)) {
+		t.Fatal("PNG() modules are not where expected")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	q, err := Encode(testLink, Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	width := q.Size() + 2*defaultQuietZone
+
+	data, err := SVG(testLink, WithSize(512))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var svg struct {
+		Width   string     `xml:"width,attr"`
+		Height  string     `xml:"height,attr"`
+		ViewBox string     `xml:"viewBox,attr"`
+		Images  []struct{} `xml:"image"`
+	}
+	if err := xml.Unmarshal(data, &svg); err != nil {
+		t.Fatalf("SVG() is not valid XML: %v", err)
+	}
+	if svg.Width != "512" || svg.Height != "512" || svg.ViewBox != fmt.Sprintf("0 0 %d %d", width, width) {
+		t.Fatalf("SVG() dimensions = %s x %s, viewBox %q", svg.Width, svg.Height, svg.ViewBox)
+	}
+	if len(svg.Images) != 0 {
+		t.Fatal("SVG() without logo has an image")
+	}
+
+	data, err = SVG(testLink, WithLogo(testLogo()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(data, &svg); err != nil || len(svg.Images) != 1 {
+		t.Fatalf("SVG() with logo has %d images, error %v", len(svg.Images), err)
+	}
+}
+
+func isDark(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+
+	return r+g+b < 3*0x8000
+}
+
+func testLogo() image.Image {
+	logo := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(logo, logo.Bounds(), image.Black, image.Point{}, draw.Src)
+
+	return logo
+}
@@ -0,0 +1,54 @@
+package qrcode
+
+import (
+	"strings"
+)
+
+// Terminal renders content as text with Unicode half blocks, each character
+// holds two rows of modules. By default light modules are drawn with blocks,
+// which suits terminals with a dark background, see WithInverted.
+func Terminal(content string, options ...renderOpt) (string, error) {
+	q, opts, err := encode(content, options)
+	if err != nil {
+		return "", err
+	}
+
+	width := q.size + 2*opts.QuietZone
+	// The block is drawn in the foreground color, which is light unless the
+	// output is inverted. The half row below an odd width is left empty.
+	filled := func(x, y int) bool {
+		if y >= width {
+			return false
+		}
+
+		return q.Dark(x-opts.QuietZone, y-opts.QuietZone) == opts.Inverted
+	}
+
+	var sb strings.Builder
+	for y := 0; y < width; y += 2 {
+		for x := 0; x < width; x++ {
+			top, bottom := filled(x, y), filled(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String(), nil
+}
+
+// WithInverted draws dark modules with blocks in Terminal output, for
+// terminals with a light background.
+func WithInverted() renderOpt {
+	return func(opts *renderOptions) {
+		opts.Inverted = true
+	}
+}
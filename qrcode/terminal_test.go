@@ -0,0 +1,89 @@
+package qrcode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []renderOpt
+		golden  string
+	}{
+		{name: "default", golden: "terminal.txt"},
+		{name: "inverted", options: []renderOpt{WithInverted()}, golden: "terminal_inverted.txt"},
+		{name: "no quiet zone", options: []renderOpt{WithQuietZone(0)}, golden: "terminal_no_quiet_zone.txt"},
+	}
+
+	// Version 1 is 21 modules wide, so with the default quiet zone the width
+	// is odd and the last row of characters is half empty.
+	const content = "tonconnect"
+	q, err := Encode(content, Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Terminal(content, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("Terminal() differs from %s:\n%s", path, got)
+			}
+
+			_, opts, _ := encode(content, tt.options)
+			checkTerminalModules(t, q, got, opts)
+		})
+	}
+}
+
+// checkTerminalModules reads the modules back from the half blocks.
+func checkTerminalModules(t *testing.T, q *QRCode, out string, opts *renderOptions) {
+	t.Helper()
+
+	width := q.Size() + 2*opts.QuietZone
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != (width+1)/2 {
+		t.Fatalf("Terminal() has %d lines, want %d", len(lines), (width+1)/2)
+	}
+
+	for i, line := range lines {
+		runes := []rune(line)
+		if len(runes) != width {
+			t.Fatalf("line %d is %d characters wide, want %d", i, len(runes), width)
+		}
+		for x, r := range runes {
+			top := r == '█' || r == '▀'
+			bottom := r == '█' || r == '▄'
+			y := 2 * i
+			if q.Dark(x-opts.QuietZone, y-opts.QuietZone) != (top == opts.Inverted) {
+				t.Fatalf("module %d, %d is drawn as %q", x, y, r)
+			}
+			if y+1 == width {
+				if bottom {
+					t.Fatalf("half row below the code at column %d is drawn as %q", x, r)
+				}
+				continue
+			}
+			if q.Dark(x-opts.QuietZone, y+1-opts.QuietZone) != (bottom == opts.Inverted) {
+				t.Fatalf("module %d, %d is drawn as %q", x, y+1, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package tonconnect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/sync/singleflight"
+)
+
+const WalletsListURL = "https://raw.githubusercontent.com/ton-blockchain/wallets-list/main/wallets-v2.json"
+
+// WalletRegistry loads wallets in the wallets-v2.json format of the official
+// wallets list and caches them. It falls back to the built-in Wallets when
+// the list can't be loaded.
+type WalletRegistry struct {
+	source  string
+	client  *http.Client
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu          sync.Mutex
+	wallets     []Wallet
+	etag        string
+	nextRefresh time.Time
+	group       singleflight.Group
+}
+
+const (
+	// registryRetryDelay is how long a registry keeps serving its cache after
+	// a failed refresh before trying again.
+	registryRetryDelay = time.Minute
+	registryTimeout    = 30 * time.Second
+)
+
+type registryOpt = func(*WalletRegistry)
+
+func NewWalletRegistry(options ...registryOpt) *WalletRegistry {
+	r := &WalletRegistry{source: WalletsListURL, client: &http.Client{Timeout: registryTimeout}, ttl: time.Hour, timeout: registryTimeout}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Wallets returns the cached wallets, refreshing them once the TTL expires or
+// a minute after a failed refresh. If the list has never been loaded
+// successfully, the built-in wallets are returned instead.
+func (r *WalletRegistry) Wallets(ctx context.Context) []Wallet {
+	r.mu.Lock()
+	stale := !time.Now().Before(r.nextRefresh)
+	r.mu.Unlock()
+
+	if stale {
+		_ = r.Refresh(ctx)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.wallets == nil {
+		return builtinWallets()
+	}
+
+	return slices.Clone(r.wallets)
+}
+
+// Wallet returns the wallet with the given app name.
+func (r *WalletRegistry) Wallet(ctx context.Context, appName string) (Wallet, bool) {
+	for _, w := range r.Wallets(ctx) {
+		if w.AppName == appName {
+			return w, true
+		}
+	}
+
+	return Wallet{}, false
+}
+
+// Refresh loads the wallets list now. On failure the previously loaded
+// wallets are kept. Concurrent calls share a single load.
+func (r *WalletRegistry) Refresh(ctx context.Context) error {
+	// The load is shared, so one caller giving up must not fail the others,
+	// and it is bounded regardless of the HTTP client's own timeout.
+	ch := r.group.DoChan("refresh", func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+		defer cancel()
+
+		return nil, r.load(ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		return res.Err
+	}
+}
+
+func (r *WalletRegistry) load(ctx context.Context) error {
+	wallets, etag, err := r.read(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.nextRefresh = time.Now().Add(min(r.ttl, registryRetryDelay))
+		return err
+	}
+
+	r.nextRefresh = time.Now().Add(r.ttl)
+	// The list has not been modified since the last fetch.
+	if wallets == nil {
+		return nil
+	}
+
+	r.wallets = wallets
+	r.etag = etag
+
+	return nil
+}
+
+func (r *WalletRegistry) read(ctx context.Context) ([]Wallet, string, error) {
+	var data []byte
+	var etag string
+	var err error
+	if strings.HasPrefix(r.source, "http://") || strings.HasPrefix(r.source, "https://") {
+		data, etag, err = r.fetch(ctx)
+	} else {
+		data, err = os.ReadFile(r.source)
+		if err != nil {
+			err = fmt.Errorf("tonconnect: failed to read wallets list: %w", err)
+		}
+	}
+	if err != nil || data == nil {
+		return nil, "", err
+	}
+
+	var wallets []Wallet
+	if err := json.Unmarshal(data, &wallets); err != nil {
+		return nil, "", fmt.Errorf("tonconnect: failed to unmarshal wallets list: %w", err)
+	}
+	for i := range wallets {
+		wallets[i].BridgeURL = wallets[i].sseBridgeURL()
+	}
+
+	return wallets, etag, nil
+}
+
+// Find returns the wallets matching all filters. Wallets keep the order of the
+// wallets list, so every caller gets the same ranking for the same list.
+func (r *WalletRegistry) Find(ctx context.Context, filters ...walletFilter) []Wallet {
+	return filterWallets(r.Wallets(ctx), filters...)
+}
+
+func filterWallets(wallets []Wallet, filters ...walletFilter) []Wallet {
+	res := make([]Wallet, 0, len(wallets))
+	for _, w := range wallets {
+		if slices.IndexFunc(filters, func(f walletFilter) bool { return !f(w) }) == -1 {
+			res = append(res, w)
+		}
+	}
+
+	return res
+}
+
+func (r *WalletRegistry) fetch(ctx context.Context) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.source, http.NoBody)
+	if err != nil {
+		return nil, "", fmt.Errorf("tonconnect: failed to initialize HTTP request: %w", err)
+	}
+
+	r.mu.Lock()
+	if r.etag != "" && r.wallets != nil {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	r.mu.Unlock()
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("tonconnect: failed to fetch wallets list: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", nil
+	default:
+		return nil, "", fmt.Errorf("tonconnect: failed to fetch wallets list: unexpected status code %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("tonconnect: failed to read wallets list: %w", err)
+	}
+
+	return data, res.Header.Get("ETag"), nil
+}
+
+func builtinWallets() []Wallet {
+	keys := maps.Keys(Wallets)
+	slices.Sort(keys)
+
+	wallets := make([]Wallet, 0, len(keys))
+	for _, key := range keys {
+		wallets = append(wallets, Wallets[key])
+	}
+
+	return wallets
+}
+
+// WithRegistrySource loads the wallets list from an HTTP(S) URL or a local
+// file path.
+func WithRegistrySource(source string) registryOpt {
+	return func(r *WalletRegistry) {
+		r.source = source
+	}
+}
+
+func WithRegistryTTL(ttl time.Duration) registryOpt {
+	return func(r *WalletRegistry) {
+		r.ttl = ttl
+	}
+}
+
+func WithRegistryHTTPClient(client *http.Client) registryOpt {
+	return func(r *WalletRegistry) {
+		r.client = client
+	}
+}
+
+type walletFilter = func(Wallet) bool
+
+func OnPlatform(platform string) walletFilter {
+	return func(w Wallet) bool {
+		return w.SupportsPlatform(platform)
+	}
+}
+
+func ViaBridge(typ string) walletFilter {
+	return func(w Wallet) bool {
+		return w.HasBridge(typ)
+	}
+}
+
+// SupportingSendTransaction matches wallets accepting at least maxMessages
+// messages per transaction. The built-in Wallets declare no features, so it
+// matches nothing until the registry has been loaded.
+func SupportingSendTransaction(maxMessages uint64) walletFilter {
+	return func(w Wallet) bool {
+		f, ok := w.Features.SendTransaction()
+		if !ok {
+			return false
+		}
+		limit := f.MaxMessages
+		if limit == 0 {
+			limit = legacyMaxMessages
+		}
+
+		return limit >= maxMessages
+	}
+}
+
+// SupportingSignData matches wallets able to sign all of the given data
+// types, or any data when no types are given. Like SupportingSendTransaction
+// it needs a loaded registry.
+func SupportingSignData(types ...string) walletFilter {
+	return func(w Wallet) bool {
+		f, ok := w.Features.SignData()
+		if !ok {
+			return false
+		}
+		for _, typ := range types {
+			if !slices.Contains(f.Types, typ) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
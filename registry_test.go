@@ -0,0 +1,155 @@
+package tonconnect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testWalletsList = `[
+	{
+		"app_name": "tonkeeper",
+		"name": "Tonkeeper",
+		"universal_url": "https://app.tonkeeper.com/ton-connect",
+		"bridge": [{"type": "sse", "url": "https://bridge.tonapi.io/bridge"}, {"type": "js", "key": "tonkeeper"}],
+		"platforms": ["ios", "android", "chrome"],
+		"features": [{"name": "SendTransaction", "maxMessages": 255}, {"name": "SignData", "types": ["text", "binary", "cell"]}]
+	},
+	{
+		"app_name": "telegram-wallet",
+		"name": "Wallet",
+		"universal_url": "https://t.me/wallet?attach=wallet",
+		"bridge": [{"type": "sse", "url": "https://walletbot.me/tonconnect-bridge/bridge"}],
+		"platforms": ["ios", "android", "macos", "windows", "linux"],
+		"features": ["SendTransaction", {"name": "SendTransaction", "maxMessages": 4}]
+	}
+]`
+
+func TestWalletRegistryCachesWithETag(t *testing.T) {
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testWalletsList))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := NewWalletRegistry(WithRegistrySource(srv.URL), WithRegistryTTL(time.Hour))
+	wallets := r.Wallets(ctx)
+	if len(wallets) != 2 || wallets[0].AppName != "tonkeeper" || wallets[0].BridgeURL != "https://bridge.tonapi.io/bridge" {
+		t.Fatalf("Wallets() = %+v", wallets)
+	}
+
+	r.Wallets(ctx)
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("requests within TTL = %d, want 1", n)
+	}
+
+	if err := r.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := notModified.Load(); n != 1 {
+		t.Fatalf("not modified responses = %d, want 1", n)
+	}
+	if wallets := r.Wallets(ctx); len(wallets) != 2 {
+		t.Fatalf("Wallets() after 304 = %+v", wallets)
+	}
+}
+
+func TestWalletRegistryFailure(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := NewWalletRegistry(WithRegistrySource(srv.URL))
+
+	var wg sync.WaitGroup
+	results := make([][]Wallet, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.Wallets(ctx)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("concurrent requests = %d, want 1", n)
+	}
+	for _, wallets := range results {
+		if len(wallets) != len(Wallets) {
+			t.Fatalf("Wallets() = %d wallets, want the %d built-in ones", len(wallets), len(Wallets))
+		}
+	}
+
+	// A failed refresh is not retried right away.
+	r.Wallets(ctx)
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("requests after failure = %d, want 1", n)
+	}
+}
+
+func TestWalletRegistryFile(t *testing.T) {
+	r := NewWalletRegistry(WithRegistrySource(t.TempDir() + "/missing.json"))
+	if err := r.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() of a missing file error = nil")
+	}
+	if w, ok := r.Wallet(context.Background(), "tonkeeper"); !ok || w.Name != "Tonkeeper" {
+		t.Fatalf("Wallet() = %+v, %v, want the built-in Tonkeeper", w, ok)
+	}
+}
+
+func TestFindWalletsByFeature(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testWalletsList))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := NewWalletRegistry(WithRegistrySource(srv.URL))
+
+	got := r.Find(ctx, SupportingSendTransaction(legacyMaxMessages))
+	if len(got) != 2 {
+		t.Fatalf("Find(SendTransaction) = %d wallets, want 2", len(got))
+	}
+	got = r.Find(ctx, SupportingSendTransaction(legacyMaxMessages+1))
+	if len(got) != 1 || got[0].AppName != "tonkeeper" {
+		t.Fatalf("Find(SendTransaction above the legacy limit) = %+v, want Tonkeeper", got)
+	}
+	got = r.Find(ctx, SupportingSignData("text", "cell"))
+	if len(got) != 1 || got[0].AppName != "tonkeeper" {
+		t.Fatalf("Find(SignData) = %+v, want Tonkeeper", got)
+	}
+}
+
+func TestFindBuiltinWalletsByFeature(t *testing.T) {
+	r := NewWalletRegistry(WithRegistrySource(t.TempDir() + "/missing.json"))
+	ctx := context.Background()
+
+	// Features of the built-in wallets are unknown.
+	if got := r.Find(ctx, SupportingSendTransaction(1)); len(got) != 0 {
+		t.Fatalf("Find(SendTransaction) = %+v, want none", got)
+	}
+	if got := r.Find(ctx); len(got) != len(Wallets) {
+		t.Fatalf("Find() = %d wallets, want %d", len(got), len(Wallets))
+	}
+}
@@ -0,0 +1,74 @@
+package tonconnect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevinburke/nacl/scalarmult"
+)
+
+type restoreOptions struct {
+	Timeout time.Duration
+}
+
+type restoreOpt = func(*restoreOptions)
+
+// Restore checks a session loaded from storage and processes the wallet
+// events the bridge queued since LastEventID, such as a disconnect. It
+// reports whether the connection to the wallet is still usable, and fails if
+// the bridge couldn't be reached.
+//
+// The bridge doesn't mark the end of the queued events, so unless the wallet
+// has disconnected Restore listens for the whole timeout (5 seconds by
+// default, see WithRestoreTimeout).
+func (s *Session) Restore(ctx context.Context, options ...restoreOpt) (bool, error) {
+	opts := &restoreOptions{Timeout: 5 * time.Second}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if s.ID == nil || s.PrivateKey == nil {
+		return false, fmt.Errorf("tonconnect: session key pair is empty")
+	}
+	if pub := scalarmult.Base(s.PrivateKey); !bytes.Equal(pub[:], s.ID[:]) {
+		return false, fmt.Errorf("tonconnect: session ID does not match its private key")
+	}
+
+	clientID, bridgeURL := s.peer()
+	if clientID == nil || bridgeURL == "" {
+		return false, nil
+	}
+	if _, err := url.Parse(bridgeURL); err != nil {
+		return false, fmt.Errorf("tonconnect: failed to parse bridge URL: %w", err)
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	var opened atomic.Bool
+	if err := s.subscribe(rctx, func() { opened.Store(true) }); err != nil {
+		return false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	// Without an open stream no disconnect could have been delivered.
+	if !opened.Load() {
+		return false, fmt.Errorf("tonconnect: failed to connect to bridge within %v", opts.Timeout)
+	}
+
+	_, bridgeURL = s.peer()
+
+	return bridgeURL != "", nil
+}
+
+// WithRestoreTimeout sets how long Restore waits for queued bridge events.
+// The bridge usually delivers them within a second of connecting.
+func WithRestoreTimeout(timeout time.Duration) restoreOpt {
+	return func(opts *restoreOptions) {
+		opts.Timeout = timeout
+	}
+}
@@ -0,0 +1,135 @@
+package tonconnect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/nacl/box"
+)
+
+func TestRestore(t *testing.T) {
+	walletID, walletKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		events    []string
+		wantAlive bool
+		wantFast  bool
+	}{
+		{name: "still connected", wantAlive: true},
+		{name: "queued disconnect", events: []string{`{"event":"disconnect","id":"7","payload":{}}`}, wantFast: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(http.StatusOK)
+				for i, event := range tt.events {
+					data, _ := json.Marshal(map[string]any{
+						"from":    hex.EncodeToString(walletID[:]),
+						"message": box.EasySeal([]byte(event), s.ID, walletKey),
+					})
+					fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", i+1, data)
+				}
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}))
+			defer srv.Close()
+
+			s.ClientID = walletID
+			s.BridgeURL = srv.URL
+
+			const timeout = 500 * time.Millisecond
+			start := time.Now()
+			alive, err := s.Restore(context.Background(), WithRestoreTimeout(timeout))
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if alive != tt.wantAlive {
+				t.Errorf("Restore() = %v, want %v", alive, tt.wantAlive)
+			}
+			if elapsed := time.Since(start); tt.wantFast && elapsed >= timeout {
+				t.Errorf("Restore() took %v, want it to return on disconnect", elapsed)
+			}
+			if !tt.wantAlive && (s.BridgeURL != "" || s.ClientID != nil) {
+				t.Error("session was not reset after disconnect")
+			}
+			if s.LastEventID != uint64(len(tt.events)) {
+				t.Errorf("LastEventID = %d, want %d", s.LastEventID, len(tt.events))
+			}
+		})
+	}
+}
+
+func TestRestoreWithoutConnection(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alive, err := s.Restore(context.Background())
+	if err != nil || alive {
+		t.Fatalf("Restore() = %v, %v, want false, nil", alive, err)
+	}
+
+	s.ID[0] ^= 1
+	if _, err := s.Restore(context.Background()); err == nil {
+		t.Fatal("Restore() with mismatched key pair error = nil")
+	}
+}
+
+func TestRestoreUnreachableBridge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		bridgeURL string
+		options   []sessionOpt
+	}{
+		{name: "bad gateway", bridgeURL: srv.URL},
+		{name: "bad gateway without retries", bridgeURL: srv.URL, options: []sessionOpt{WithMaxRetries(0)}},
+		{name: "connection refused", bridgeURL: "http://127.0.0.1:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSession(tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			walletID, _, err := box.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.ClientID = walletID
+			s.BridgeURL = tt.bridgeURL
+
+			alive, err := s.Restore(context.Background(), WithRestoreTimeout(300*time.Millisecond))
+			if err == nil || alive {
+				t.Fatalf("Restore() = %v, %v, want false and an error", alive, err)
+			}
+			if s.ClientID == nil {
+				t.Fatal("session was reset although the bridge wasn't reached")
+			}
+		})
+	}
+}
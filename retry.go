@@ -0,0 +1,81 @@
+package tonconnect
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+type retryPolicy struct {
+	MaxRetries      uint64
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	MaxRetries:      5,
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+}
+
+func (s *Session) retryPolicy() retryPolicy {
+	if s.retry != nil {
+		return *s.retry
+	}
+
+	return defaultRetryPolicy
+}
+
+// backOff returns an exponential backoff with jitter which gives up once the
+// message TTL (in seconds) has elapsed. Without a TTL only the number of
+// retries is limited.
+func (p retryPolicy) backOff(ctx context.Context, ttl string) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.InitialInterval
+	b.MaxInterval = p.MaxInterval
+	b.MaxElapsedTime = 0
+	if secs, err := strconv.ParseUint(ttl, 10, 32); err == nil {
+		b.MaxElapsedTime = time.Duration(secs) * time.Second
+	}
+	b.Reset()
+
+	return backoff.WithContext(backoff.WithMaxRetries(b, p.MaxRetries), ctx)
+}
+
+// isRetryable reports whether sending a message failed because of a network
+// error, rate limiting or a bridge server error.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var berr *BridgeError
+	if errors.As(err, &berr) {
+		return berr.Temporary()
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// WithMaxRetries sets how many times a failed bridge message is resent and a
+// dropped event stream is reopened in a row. Zero disables retries.
+func WithMaxRetries(n uint64) sessionOpt {
+	return func(s *Session) {
+		p := s.retryPolicy()
+		p.MaxRetries = n
+		s.retry = &p
+	}
+}
+
+// WithRetryInterval sets the initial and maximum delay between retries.
+func WithRetryInterval(initial, maximum time.Duration) sessionOpt {
+	return func(s *Session) {
+		p := s.retryPolicy()
+		p.InitialInterval = initial
+		p.MaxInterval = maximum
+		s.retry = &p
+	}
+}
@@ -4,11 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
-
-	"golang.org/x/sync/errgroup"
 )
 
 type sendTransactionRequest struct {
@@ -32,102 +31,180 @@ type Message struct {
 }
 
 type sendTransactionResponse struct {
-	ID     string `json:"id"`
-	Result []byte `json:"result,omitempty"`
-	Error  *struct {
-		Code    uint64 `json:"code"`
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
+	ID     string       `json:"id"`
+	Result []byte       `json:"result,omitempty"`
+	Error  *WalletError `json:"error,omitempty"`
 }
 
 type txOpt = func(*Transaction)
 
 type msgOpt = func(*Message)
 
+// maxTransactionMessages is the protocol limit, wallets may advertise a lower
+// one with the SendTransaction feature.
+const maxTransactionMessages = 255
+
+var (
+	ErrInvalidTransaction = errors.New("tonconnect: invalid transaction")
+	ErrTooManyMessages    = errors.New("tonconnect: too many messages")
+)
+
+// SendTransaction asks the wallet to sign and send tx. From and Network are
+// filled in from the connected account when empty.
 func (s *Session) SendTransaction(ctx context.Context, tx Transaction, options ...bridgeMessageOption) ([]byte, error) {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	g, ctx := errgroup.WithContext(ctx)
-	msgs := make(chan bridgeMessage)
-
-	id := s.LastRequestID + 1
-	g.Go(func() error {
-		tr, err := json.Marshal(tx)
+	s.mu.Lock()
+	account := s.Account
+	limit := s.maxMessages()
+	s.mu.Unlock()
+
+	if account != nil {
+		network := strconv.FormatInt(account.Network, 10)
+		if tx.From == "" {
+			tx.From = account.Address
+		}
+		if tx.Network == "" && account.Network != 0 {
+			tx.Network = network
+		}
+		if account.Network != 0 && tx.Network != network {
+			return nil, fmt.Errorf("%w: network %s does not match connected wallet network %s", ErrInvalidTransaction, tx.Network, network)
+		}
+	}
+
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	if uint64(len(tx.Messages)) > limit {
+		return nil, fmt.Errorf("%w: %d messages exceed the wallet limit of %d", ErrTooManyMessages, len(tx.Messages), limit)
+	}
+
+	tr, err := json.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to marshal transaction: %w", err)
+	}
+
+	id := s.nextRequestID()
+	req := sendTransactionRequest{
+		ID:     strconv.FormatUint(id, 10),
+		Method: "sendTransaction",
+		Params: []string{string(tr)},
+	}
+
+	msg, err := s.request(ctx, id, req, "sendTransaction", options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Error != nil {
+		return nil, newProtocolError("sendTransaction", *msg.Error)
+	}
+
+	res, ok := msg.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("tonconnect: transaction result expected to be of type %q", "string")
+	}
+
+	boc, err := base64.StdEncoding.DecodeString(res)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to decode transaction result bag of cells")
+	}
+
+	return boc, nil
+}
+
+// SendTransactionBatches splits tx into transactions of at most the number of
+// messages the connected wallet accepts and sends them one after another.
+// It returns the bags of cells of the transactions sent so far.
+func (s *Session) SendTransactionBatches(ctx context.Context, tx Transaction, options ...bridgeMessageOption) ([][]byte, error) {
+	if err := tx.validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	limit := int(s.maxMessages())
+	s.mu.Unlock()
+
+	var bocs [][]byte
+	for msgs := tx.Messages; len(msgs) > 0; {
+		n := min(limit, len(msgs))
+		batch := tx
+		batch.Messages = msgs[:n]
+		msgs = msgs[n:]
+
+		boc, err := s.SendTransaction(ctx, batch, options...)
 		if err != nil {
-			return fmt.Errorf("tonconnect: failed to marshal transaction: %w", err)
+			return bocs, err
 		}
+		bocs = append(bocs, boc)
+	}
 
-		req := sendTransactionRequest{
-			ID:     strconv.FormatUint(id, 10),
-			Method: "sendTransaction",
-			Params: []string{string(tr)},
+	return bocs, nil
+}
+
+func (s *Session) maxMessages() uint64 {
+	if s.Device != nil {
+		if f, ok := s.Device.Features.SendTransaction(); ok && f.MaxMessages > 0 {
+			return min(f.MaxMessages, maxTransactionMessages)
 		}
+	}
+
+	return legacyMaxMessages
+}
+
+// Validate checks the transaction before it is sent to the wallet.
+func (tx Transaction) Validate() error {
+	if len(tx.Messages) > maxTransactionMessages {
+		return fmt.Errorf("%w: %d messages exceed the limit of %d", ErrInvalidTransaction, len(tx.Messages), maxTransactionMessages)
+	}
+
+	return tx.validate()
+}
 
-		err = s.sendMessage(ctx, req, "sendTransaction", options...)
-		if err == nil {
-			s.LastRequestID = id
+// validate checks everything but the message limit, which
+// SendTransactionBatches splits the transaction by.
+func (tx Transaction) validate() error {
+	if len(tx.Messages) == 0 {
+		return fmt.Errorf("%w: no messages", ErrInvalidTransaction)
+	}
+
+	if tx.ValidUntil != 0 && time.Unix(int64(tx.ValidUntil), 0).Before(time.Now()) {
+		return fmt.Errorf("%w: valid_until is in the past", ErrInvalidTransaction)
+	}
+
+	switch tx.Network {
+	case "", "-239", "-3":
+	default:
+		return fmt.Errorf("%w: unknown network %q", ErrInvalidTransaction, tx.Network)
+	}
+
+	if tx.From != "" {
+		if _, err := ParseAddress(tx.From); err != nil {
+			return fmt.Errorf("%w: invalid from address: %w", ErrInvalidTransaction, err)
 		}
+	}
 
-		return err
-	})
-
-	var boc []byte
-	g.Go(func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case msg := <-msgs:
-				msgID, err := msg.Message.ID.Int64()
-				if err == nil {
-					s.LastRequestID = uint64(msgID)
-				}
-
-				if int64(id) == msgID {
-					if msg.Message.Error != nil {
-						if msg.Message.Error.Message != "" {
-							return fmt.Errorf("tonconnect: %s", msg.Message.Error.Message)
-						}
-
-						switch msg.Message.Error.Code {
-						case 1:
-							return fmt.Errorf("tonconnect: bad request")
-						case 100:
-							return fmt.Errorf("tonconnect: unknown app")
-						case 300:
-							return fmt.Errorf("tonconnect: user declined the transaction")
-						case 400:
-							return fmt.Errorf("tonconnect: %q method is not supported", "sendTransaction")
-						default:
-							return fmt.Errorf("tonconnect: unknown transaction send error")
-						}
-					}
-
-					cancel()
-
-					res, ok := msg.Message.Result.(string)
-					if !ok {
-						return fmt.Errorf("tonconnect: transaction result expected to be of type %q", "string")
-					}
-
-					boc, err = base64.StdEncoding.DecodeString(res)
-					if err != nil {
-						return fmt.Errorf("tonconnect: failed to decode transaction result bag of cells")
-					}
-
-					return nil
-				}
-			}
+	for i, msg := range tx.Messages {
+		if _, err := ParseAddress(msg.Address); err != nil {
+			return fmt.Errorf("%w: message %d: %w", ErrInvalidTransaction, i, err)
 		}
-	})
+		if !isNanotons(msg.Amount) {
+			return fmt.Errorf("%w: message %d: amount %q is not a decimal number of nanotons", ErrInvalidTransaction, i, msg.Amount)
+		}
+	}
 
-	g.Go(func() error {
-		return s.connectToBridge(ctx, s.BridgeURL, msgs)
-	})
+	return nil
+}
 
-	err := g.Wait()
+func isNanotons(amount string) bool {
+	if amount == "" {
+		return false
+	}
+	for _, r := range amount {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
 
-	return boc, err
+	return true
 }
 
 func NewTransaction(options ...txOpt) (*Transaction, error) {
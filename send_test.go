@@ -0,0 +1,179 @@
+package tonconnect
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testTransaction(n int) Transaction {
+	tx := Transaction{ValidUntil: uint64(time.Now().Add(time.Minute).Unix())}
+	for i := 0; i < n; i++ {
+		tx.Messages = append(tx.Messages, Message{Address: testAddresses[i%len(testAddresses)].Bounceable, Amount: "1000000000"})
+	}
+
+	return tx
+}
+
+func TestTransactionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(tx *Transaction)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(tx *Transaction) {}},
+		{name: "raw address and testnet", modify: func(tx *Transaction) {
+			tx.Network = "-3"
+			tx.From = testAddresses[3].Raw
+			tx.Messages[0].Address = testAddresses[1].Raw
+		}},
+		{name: "no valid_until", modify: func(tx *Transaction) { tx.ValidUntil = 0 }},
+		{name: "no messages", modify: func(tx *Transaction) { tx.Messages = nil }, wantErr: true},
+		{name: "exponent amount", modify: func(tx *Transaction) { tx.Messages[0].Amount = "1e9" }, wantErr: true},
+		{name: "negative amount", modify: func(tx *Transaction) { tx.Messages[0].Amount = "-1" }, wantErr: true},
+		{name: "empty amount", modify: func(tx *Transaction) { tx.Messages[0].Amount = "" }, wantErr: true},
+		{name: "fractional amount", modify: func(tx *Transaction) { tx.Messages[0].Amount = "1.5" }, wantErr: true},
+		{name: "expired", modify: func(tx *Transaction) { tx.ValidUntil = uint64(time.Now().Add(-time.Minute).Unix()) }, wantErr: true},
+		{name: "bad address", modify: func(tx *Transaction) { tx.Messages[0].Address = "EQDoJ1hmy" }, wantErr: true},
+		{name: "bad from", modify: func(tx *Transaction) { tx.From = "0:00" }, wantErr: true},
+		{name: "unknown network", modify: func(tx *Transaction) { tx.Network = "1" }, wantErr: true},
+		{name: "too many messages", modify: func(tx *Transaction) { *tx = testTransaction(maxTransactionMessages + 1) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := testTransaction(2)
+			tt.modify(&tx)
+
+			err := tx.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTransaction) {
+				t.Fatalf("Validate() error = %v, want %v", err, ErrInvalidTransaction)
+			}
+		})
+	}
+}
+
+func TestSendTransactionNetworkMismatch(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Account = &TonAddrItem{Address: testAddresses[0].Raw, Network: -239}
+
+	tx := testTransaction(1)
+	tx.Network = "-3"
+	if _, err := s.SendTransaction(context.Background(), tx); !errors.Is(err, ErrInvalidTransaction) {
+		t.Fatalf("SendTransaction() error = %v, want %v", err, ErrInvalidTransaction)
+	}
+}
+
+func TestSendTransactionMaxMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		features Features
+		want     uint64
+	}{
+		{name: "no device info", want: legacyMaxMessages},
+		{name: "legacy", features: Features{SendTransactionFeature{MaxMessages: legacyMaxMessages, Legacy: true}}, want: legacyMaxMessages},
+		{name: "typed", features: Features{SendTransactionFeature{MaxMessages: 2}}, want: 2},
+		{name: "above protocol limit", features: Features{SendTransactionFeature{MaxMessages: 1000}}, want: maxTransactionMessages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.features != nil {
+				s.Device = &DeviceInfo{Features: tt.features}
+			}
+			if got := s.maxMessages(); got != tt.want {
+				t.Fatalf("maxMessages() = %d, want %d", got, tt.want)
+			}
+
+			// The limit is enforced before anything is sent, the protocol
+			// limit by Validate.
+			want := ErrTooManyMessages
+			if tt.want == maxTransactionMessages {
+				want = ErrInvalidTransaction
+			}
+			_, err = s.SendTransaction(context.Background(), testTransaction(int(tt.want)+1))
+			if !errors.Is(err, want) {
+				t.Fatalf("SendTransaction() error = %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+func TestSendTransactionBatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     uint64
+		messages  int
+		wantSizes []int
+	}{
+		{name: "legacy limit", limit: legacyMaxMessages, messages: 10, wantSizes: []int{4, 4, 2}},
+		{name: "single batch", limit: 255, messages: 3, wantSizes: []int{3}},
+		{name: "above protocol limit", limit: 255, messages: 300, wantSizes: []int{255, 45}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Device = &DeviceInfo{Features: Features{SendTransactionFeature{MaxMessages: tt.limit}}}
+			w := newFakeWallet(t, s)
+			w.result = base64.StdEncoding.EncodeToString([]byte("boc"))
+			w.requests = make(chan []byte, len(tt.wantSizes))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			go s.Listen(ctx, nil)
+			waitListening(s)
+
+			bocs, err := s.SendTransactionBatches(ctx, testTransaction(tt.messages))
+			if err != nil {
+				t.Fatalf("SendTransactionBatches() error = %v", err)
+			}
+			if len(bocs) != len(tt.wantSizes) {
+				t.Fatalf("SendTransactionBatches() = %d results, want %d", len(bocs), len(tt.wantSizes))
+			}
+
+			for i, want := range tt.wantSizes {
+				var req sendTransactionRequest
+				if err := json.Unmarshal(<-w.requests, &req); err != nil {
+					t.Fatal(err)
+				}
+				var tx Transaction
+				if err := json.Unmarshal([]byte(req.Params[0]), &tx); err != nil {
+					t.Fatal(err)
+				}
+				if len(tx.Messages) != want {
+					t.Errorf("batch %d has %d messages, want %d", i, len(tx.Messages), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSendTransactionBatchesValidates(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := testTransaction(300)
+	tx.Messages[299].Amount = "-1"
+	if _, err := s.SendTransactionBatches(context.Background(), tx); !errors.Is(err, ErrInvalidTransaction) {
+		t.Fatalf("SendTransactionBatches() error = %v, want %v", err, ErrInvalidTransaction)
+	}
+}
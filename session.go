@@ -12,7 +12,11 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/nacl/box"
 	"github.com/tmaxmax/go-sse"
@@ -22,11 +26,35 @@ type Session struct {
 	ID            nacl.Key `json:"id"`
 	PrivateKey    nacl.Key `json:"private_key"`
 	ClientID      nacl.Key `json:"client_id,omitempty"`
-	BridgeURL     string   `json:"brdige_url,omitempty"`
+	BridgeURL     string   `json:"bridge_url,omitempty"`
 	LastEventID   uint64   `json:"last_event_id,string,omitempty"`
 	LastRequestID uint64   `json:"last_request_id,string,omitempty"`
+
+	// Account and Device describe the connected wallet.
+	Account *TonAddrItem `json:"account,omitempty"`
+	Device  *DeviceInfo  `json:"device,omitempty"`
+
+	mu        sync.Mutex
+	pending   map[uint64]*pendingRequest
+	listening bool
+	events    *eventQueue
+	unlisten  context.CancelFunc
+
+	onDisconnect func(*Session)
+	store        SessionStore
+	storeKey     string
+	encrypter    Encrypter
+	httpClient   *http.Client
+	retry        *retryPolicy
+	saveMu       sync.Mutex
 }
 
+type sessionOpt = func(*Session)
+
+// sessionVersion is the current version of the Session JSON encoding.
+// Sessions encoded before versioning was introduced are treated as version 1.
+const sessionVersion uint64 = 2
+
 type bridgeMessageOptions struct {
 	TTL   string
 	Topic string
@@ -34,18 +62,94 @@ type bridgeMessageOptions struct {
 
 type bridgeMessageOption = func(*bridgeMessageOptions)
 
-func NewSession() (*Session, error) {
+func NewSession(options ...sessionOpt) (*Session, error) {
 	id, pk, err := box.GenerateKey(rand.Reader)
 	if err != nil {
 		return nil, fmt.Errorf("tonconnect: failed to generate key pair: %w", err)
 	}
 
 	s := &Session{ID: id, PrivateKey: pk, LastRequestID: 1}
+	for _, opt := range options {
+		opt(s)
+	}
 
 	return s, nil
 }
 
-func (s *Session) connectToBridge(ctx context.Context, bridgeURL string, msgs chan<- bridgeMessage) error {
+// MarshalJSON encodes the session with the current schema version, replacing
+// the private key with its sealed form when the session has an Encrypter.
+func (s *Session) MarshalJSON() ([]byte, error) {
+	type session Session
+	v := struct {
+		Version uint64 `json:"version"`
+		*session
+		PrivateKey          nacl.Key   `json:"private_key,omitempty"`
+		EncryptedPrivateKey *sealedKey `json:"encrypted_private_key,omitempty"`
+	}{Version: sessionVersion, session: (*session)(s)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v.PrivateKey = s.PrivateKey
+
+	if s.encrypter != nil && s.PrivateKey != nil {
+		sealed, err := sealPrivateKey(s.encrypter, s.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		v.PrivateKey = nil
+		v.EncryptedPrivateKey = sealed
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes any known session schema version.
+func (s *Session) UnmarshalJSON(data []byte) error {
+	type session Session
+	v := struct {
+		Version uint64 `json:"version"`
+		*session
+		EncryptedPrivateKey *sealedKey `json:"encrypted_private_key,omitempty"`
+		// Version 1 sessions were encoded with a misspelled bridge URL key.
+		LegacyBridgeURL string `json:"brdige_url,omitempty"`
+	}{session: (*session)(s)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.Version > sessionVersion {
+		return fmt.Errorf("tonconnect: unsupported session version %d", v.Version)
+	}
+
+	if s.BridgeURL == "" {
+		s.BridgeURL = v.LegacyBridgeURL
+	}
+
+	if v.EncryptedPrivateKey != nil {
+		if s.encrypter == nil {
+			return fmt.Errorf("tonconnect: session private key is encrypted but no encrypter is set")
+		}
+
+		pk, err := openPrivateKey(s.encrypter, v.EncryptedPrivateKey)
+		if err != nil {
+			return err
+		}
+		s.PrivateKey = pk
+	}
+
+	return nil
+}
+
+// connectToBridge delivers wallet messages from the bridge to msgs until ctx
+// is done. A dropped event stream is reopened from LastEventID, giving up
+// after the retry policy's number of consecutive failed attempts. When set,
+// opened is called each time the bridge accepts the event stream.
+func (s *Session) connectToBridge(ctx context.Context, bridgeURL string, msgs chan<- bridgeMessage, opened func()) error {
 	if s.ID == nil || s.PrivateKey == nil {
 		return fmt.Errorf("tonconnect: session key pair is empty")
 	}
@@ -54,22 +158,69 @@ func (s *Session) connectToBridge(ctx context.Context, bridgeURL string, msgs ch
 	if err != nil {
 		return fmt.Errorf("tonconnect: failed to parse bridge URL: %w", err)
 	}
-
 	u = u.JoinPath("/events")
+
+	b := s.retryPolicy().backOff(ctx, "")
+	for {
+		received, err := s.streamEvents(ctx, u, bridgeURL, msgs, opened)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Any event, including a heartbeat, means the stream was healthy.
+		if received {
+			b.Reset()
+		}
+
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			return fmt.Errorf("tonconnect: failed to connect to bridge: %w", err)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// streamEvents reads a single event stream until it breaks. It reports
+// whether any event was received.
+func (s *Session) streamEvents(ctx context.Context, u *url.URL, bridgeURL string, msgs chan<- bridgeMessage, opened func()) (bool, error) {
 	q := u.Query()
 	q.Set("client_id", hex.EncodeToString(s.ID[:]))
+	s.mu.Lock()
 	if s.LastEventID > 0 {
-		q.Set("last_event_id", strconv.FormatUint(uint64(s.LastEventID), 10))
+		q.Set("last_event_id", strconv.FormatUint(s.LastEventID, 10))
 	}
+	s.mu.Unlock()
 	u.RawQuery = q.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
 	if err != nil {
-		return fmt.Errorf("tonconnect: failed to initialize HTTP request: %w", err)
+		return false, fmt.Errorf("tonconnect: failed to initialize HTTP request: %w", err)
 	}
 
-	conn := sse.NewConnection(req)
+	var received atomic.Bool
+	client := &sse.Client{
+		HTTPClient: s.client(),
+		ResponseValidator: func(res *http.Response) error {
+			if err := sse.DefaultValidator(res); err != nil {
+				return err
+			}
+			if opened != nil {
+				opened()
+			}
+
+			return nil
+		},
+	}
+	conn := client.NewConnection(req)
 	unsub := conn.SubscribeEvent("message", func(e sse.Event) {
+		received.Store(true)
+
 		var bmsg struct {
 			From    string `json:"from"`
 			Message []byte `json:"message"`
@@ -77,25 +228,34 @@ func (s *Session) connectToBridge(ctx context.Context, bridgeURL string, msgs ch
 		if err := json.Unmarshal([]byte(e.Data), &bmsg); err == nil {
 			var msg walletMessage
 			if clientID, err := s.decrypt(bmsg.From, bmsg.Message, &msg); err == nil {
-				msgs <- bridgeMessage{BrdigeURL: bridgeURL, From: clientID, Message: msg}
 				id, err := strconv.ParseUint(e.LastEventID, 10, 64)
 				if err == nil {
+					s.mu.Lock()
 					s.LastEventID = id
+					s.mu.Unlock()
+				}
+				select {
+				case msgs <- bridgeMessage{BrdigeURL: bridgeURL, From: clientID, Message: msg}:
+				case <-ctx.Done():
 				}
 			}
 		}
 	})
 	defer unsub()
 
-	if err := conn.Connect(); !(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
-		return fmt.Errorf("tonconnect: failed to connect to bridge: %w", err)
-	}
+	unsubHeartbeat := conn.SubscribeEvent("heartbeat", func(sse.Event) {
+		received.Store(true)
+	})
+	defer unsubHeartbeat()
 
-	return nil
+	err = conn.Connect()
+
+	return received.Load(), err
 }
 
 func (s *Session) sendMessage(ctx context.Context, msg any, topic string, options ...bridgeMessageOption) error {
-	if s.ID == nil || s.PrivateKey == nil || s.ClientID == nil || s.BridgeURL == "" {
+	clientID, bridgeURL := s.peer()
+	if s.ID == nil || s.PrivateKey == nil || clientID == nil || bridgeURL == "" {
 		return fmt.Errorf("tonconnect: session not established")
 	}
 
@@ -104,7 +264,7 @@ func (s *Session) sendMessage(ctx context.Context, msg any, topic string, option
 		opt(opts)
 	}
 
-	u, err := url.Parse(s.BridgeURL)
+	u, err := url.Parse(bridgeURL)
 	if err != nil {
 		return fmt.Errorf("tonconnect: failed to parse bridge URL: %w", err)
 	}
@@ -112,7 +272,7 @@ func (s *Session) sendMessage(ctx context.Context, msg any, topic string, option
 	u = u.JoinPath("/message")
 	q := u.Query()
 	q.Set("client_id", hex.EncodeToString(s.ID[:]))
-	q.Set("to", hex.EncodeToString(s.ClientID[:]))
+	q.Set("to", hex.EncodeToString(clientID[:]))
 	if opts.TTL != "" {
 		q.Set("ttl", opts.TTL)
 	}
@@ -121,37 +281,99 @@ func (s *Session) sendMessage(ctx context.Context, msg any, topic string, option
 	}
 	u.RawQuery = q.Encode()
 
-	data, err := s.encrypt(msg)
+	data, err := s.encrypt(clientID, msg)
 	if err != nil {
 		return err
 	}
 
-	body := bytes.NewBuffer([]byte(base64.StdEncoding.EncodeToString(data)))
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
-	req.Header.Set("Content-Type", "text/plain")
+	body := []byte(base64.StdEncoding.EncodeToString(data))
+	b := s.retryPolicy().backOff(ctx, opts.TTL)
+	for {
+		err := s.postMessage(ctx, u.String(), body)
+		if err == nil || !isRetryable(ctx, err) {
+			return err
+		}
+
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		var berr *BridgeError
+		if errors.As(err, &berr) && berr.RetryAfter > wait {
+			wait = berr.RetryAfter
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Session) postMessage(ctx context.Context, u string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("tonconnect: failed to initialize HTTP request: %w", err)
 	}
-	res, err := http.DefaultClient.Do(req)
+	req.Header.Set("Content-Type", "text/plain")
+	res, err := s.client().Do(req)
 	if err != nil {
 		return fmt.Errorf("tonconnect: failed to send message: %w", err)
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		// TODO: parse response body according to https://github.com/ton-connect/bridge implementation
-		return fmt.Errorf("tonconnect: failed to send message")
+		return newBridgeError(res)
 	}
 
 	return nil
 }
 
-func (s *Session) encrypt(msg any) ([]byte, error) {
+func (s *Session) client() *http.Client {
+	if s.httpClient != nil {
+		return s.httpClient
+	}
+
+	return http.DefaultClient
+}
+
+// peer returns the connected wallet client ID and bridge URL.
+func (s *Session) peer() (nacl.Key, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ClientID, s.BridgeURL
+}
+
+// nextRequestID reserves an ID for a new request, so concurrent calls never
+// share one.
+func (s *Session) nextRequestID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.LastRequestID++
+
+	return s.LastRequestID
+}
+
+func (s *Session) observeRequestID(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id > s.LastRequestID {
+		s.LastRequestID = id
+	}
+}
+
+func (s *Session) encrypt(clientID nacl.Key, msg any) ([]byte, error) {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return nil, fmt.Errorf("tonconnect: failed to marshal message to encrypt: %w", err)
 	}
 
-	return box.EasySeal(data, s.ClientID, s.PrivateKey), nil
+	return box.EasySeal(data, clientID, s.PrivateKey), nil
 }
 
 func (s *Session) decrypt(from string, msg []byte, v any) (nacl.Key, error) {
@@ -160,7 +382,7 @@ func (s *Session) decrypt(from string, msg []byte, v any) (nacl.Key, error) {
 		return clientID, fmt.Errorf("tonconnect: failed to load client ID: %w", err)
 	}
 
-	if s.ClientID != nil && !bytes.Equal(s.ClientID[:], clientID[:]) {
+	if expected, _ := s.peer(); expected != nil && !bytes.Equal(expected[:], clientID[:]) {
 		return clientID, fmt.Errorf("tonconnect: session and bridge message client IDs don't match")
 	}
 
@@ -177,6 +399,25 @@ func (s *Session) decrypt(from string, msg []byte, v any) (nacl.Key, error) {
 	return clientID, nil
 }
 
+// WithDisconnectHandler registers a hook called when the wallet terminates
+// the connection. It runs on the bridge subscription goroutine after the
+// session has been reset.
+func WithDisconnectHandler(handler func(*Session)) sessionOpt {
+	return func(s *Session) {
+		s.onDisconnect = handler
+	}
+}
+
+// WithHTTPClient sets the client used for both the bridge event stream and
+// message delivery. The client must not have a Timeout set, since it would
+// also cut off the long-lived event stream; use context deadlines or
+// transport-level timeouts instead.
+func WithHTTPClient(client *http.Client) sessionOpt {
+	return func(s *Session) {
+		s.httpClient = client
+	}
+}
+
 func WithTTL(ttl uint64) bridgeMessageOption {
 	return func(opts *bridgeMessageOptions) {
 		opts.TTL = strconv.FormatUint(ttl, 10)
@@ -0,0 +1,177 @@
+package tonconnect
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/nacl/box"
+)
+
+func TestSessionUnmarshalJSON(t *testing.T) {
+	// Keys are encoded as JSON arrays of bytes.
+	id, pk := testKeyJSON(1), testKeyJSON(0x21)
+
+	tests := []struct {
+		name          string
+		data          string
+		wantBridgeURL string
+		wantEventID   uint64
+		wantErr       string
+	}{
+		{
+			name:          "legacy brdige_url",
+			data:          `{"id":` + id + `,"private_key":` + pk + `,"brdige_url":"https://bridge.tonapi.io/bridge","last_event_id":"42"}`,
+			wantBridgeURL: "https://bridge.tonapi.io/bridge",
+			wantEventID:   42,
+		},
+		{
+			name:          "version 2",
+			data:          `{"version":2,"id":` + id + `,"private_key":` + pk + `,"bridge_url":"https://bridge.ton.space/bridge"}`,
+			wantBridgeURL: "https://bridge.ton.space/bridge",
+		},
+		{
+			name:          "corrected key wins",
+			data:          `{"id":` + id + `,"private_key":` + pk + `,"brdige_url":"https://old","bridge_url":"https://new"}`,
+			wantBridgeURL: "https://new",
+		},
+		{
+			name:    "future version",
+			data:    `{"version":3,"id":` + id + `}`,
+			wantErr: "unsupported session version 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Session
+			err := json.Unmarshal([]byte(tt.data), &s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if s.BridgeURL != tt.wantBridgeURL {
+				t.Errorf("BridgeURL = %q, want %q", s.BridgeURL, tt.wantBridgeURL)
+			}
+			if s.LastEventID != tt.wantEventID {
+				t.Errorf("LastEventID = %d, want %d", s.LastEventID, tt.wantEventID)
+			}
+			if s.PrivateKey == nil || s.PrivateKey[0] != 0x21 {
+				t.Errorf("PrivateKey = %x, want it to start with 21", s.PrivateKey)
+			}
+		})
+	}
+}
+
+func TestSessionMarshalJSONRoundTrip(t *testing.T) {
+	enc, err := NewAESEncrypter(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSession(WithEncrypter(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.BridgeURL = "https://bridge.tonapi.io/bridge"
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"version":2`, `"bridge_url"`, `"encrypted_private_key"`} {
+		if !bytes.Contains(data, []byte(key)) {
+			t.Errorf("encoded session %s lacks %s", data, key)
+		}
+	}
+	if bytes.Contains(data, []byte(`"private_key"`)) || bytes.Contains(data, []byte(`"brdige_url"`)) {
+		t.Errorf("encoded session %s contains legacy or plaintext fields", data)
+	}
+
+	restored, err := NewSession(WithEncrypter(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored.PrivateKey[:], s.PrivateKey[:]) || restored.BridgeURL != s.BridgeURL {
+		t.Error("session changed after encoding round trip")
+	}
+}
+
+// testKeyJSON encodes a key filled with consecutive bytes starting at first.
+func testKeyJSON(first byte) string {
+	key := make([]string, 32)
+	for i := range key {
+		key[i] = strconv.Itoa(int(first) + i)
+	}
+
+	return "[" + strings.Join(key, ",") + "]"
+}
+
+func TestSendMessageRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []int
+		retryAfter   string
+		want         error
+		wantAttempts int32
+		wantMinWait  time.Duration
+	}{
+		{name: "server error and rate limit", responses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}, wantAttempts: 3},
+		{name: "bad request", responses: []int{http.StatusBadRequest, http.StatusOK}, want: ErrBridgeBadRequest, wantAttempts: 1},
+		{name: "gives up", responses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, want: ErrBridgeUnavailable, wantAttempts: 3},
+		{name: "retry after", responses: []int{http.StatusTooManyRequests, http.StatusOK}, retryAfter: "1", wantAttempts: 2, wantMinWait: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.responses[n-1])
+			}))
+			defer srv.Close()
+
+			clientID, _, err := box.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := NewSession(WithMaxRetries(2), WithRetryInterval(time.Millisecond, time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.ClientID = clientID
+			s.BridgeURL = srv.URL
+
+			start := time.Now()
+			err = s.sendMessage(context.Background(), struct{}{}, "")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("sendMessage() error = %v, want %v", err, tt.want)
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", n, tt.wantAttempts)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantMinWait {
+				t.Errorf("sendMessage() retried after %v, want at least %v", elapsed, tt.wantMinWait)
+			}
+		})
+	}
+}
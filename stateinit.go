@@ -0,0 +1,121 @@
+package tonconnect
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+)
+
+// WalletStateInit is a parsed state init of a standard wallet contract.
+type WalletStateInit struct {
+	Version   string
+	PublicKey ed25519.PublicKey
+	Address   Address
+}
+
+type walletContract struct {
+	Version string
+	// KeyOffset is the position of the public key in the data cell, in bits.
+	KeyOffset int
+}
+
+var walletContracts = map[string]walletContract{
+	"b61041a58a7980b946e8fb9e198e3c904d24799ffa36574ea4251c41a566f581": {Version: "v3r1", KeyOffset: 64},
+	"84dafa449f98a6987789ba232358072bc0f76dc4524002a5d0918b9a75d2d599": {Version: "v3r2", KeyOffset: 64},
+	"feb5ff6820e2ff0d9483e7e0d62c817d846789fb4ae580c878866d959dabd5c0": {Version: "v4r2", KeyOffset: 64},
+	"20834b7b72b112147e1b2fb457b84e74d1a30f04f737d4f62a668e9552d2b72f": {Version: "v5r1", KeyOffset: 65},
+	"203dd4f358adb49993129aa925cac39916b68a0e4f78d26e8f2c2b69eafa5679": {Version: "highload-v2r2", KeyOffset: 96},
+	"9494d1cc8edf12f05671a1a9ba09921096eb50811e1924ec65c3c629fbb80812": {Version: "highload-v2", KeyOffset: 96},
+	"11acad7955844090f283bf238bc1449871f783e7cc0979408d3f4859483e8525": {Version: "highload-v3", KeyOffset: 0},
+}
+
+// ParseWalletStateInit extracts the public key from the state init of a
+// standard wallet contract and checks that the state init hashes to the
+// given address.
+func ParseWalletStateInit(address string, stateInit []byte) (*WalletStateInit, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := parseBoC(stateInit)
+	if err != nil {
+		return nil, err
+	}
+	if root.hash != addr.Hash {
+		return nil, fmt.Errorf("tonconnect: state init does not match address %q", address)
+	}
+
+	code, data, err := parseStateInit(root)
+	if err != nil {
+		return nil, err
+	}
+
+	codeHash := code.hash
+	// Contracts deployed from a library keep only the code hash in the state
+	// init, inside a library cell: 8-bit type 2 followed by the hash.
+	if code.exotic && code.bits == 8+256 && code.data[0] == 2 {
+		copy(codeHash[:], code.data[1:])
+	}
+
+	contract, ok := walletContracts[hex.EncodeToString(codeHash[:])]
+	if !ok {
+		return nil, fmt.Errorf("tonconnect: unsupported wallet contract")
+	}
+
+	r := data.reader()
+	if err := r.skipBits(contract.KeyOffset); err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to read wallet data: %w", err)
+	}
+	pubkey, err := r.readBytes(ed25519.PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to read wallet public key: %w", err)
+	}
+
+	return &WalletStateInit{
+		Version:   contract.Version,
+		PublicKey: pubkey,
+		Address:   addr,
+	}, nil
+}
+
+// parseStateInit reads the code and data cells of
+// split_depth:(Maybe (## 5)) special:(Maybe TickTock)
+// code:(Maybe ^Cell) data:(Maybe ^Cell) library:(HashmapE 256 SimpleLib).
+func parseStateInit(c *cell) (code, data *cell, err error) {
+	r := c.reader()
+
+	fields := []struct {
+		Skip int
+		Ref  **cell
+	}{
+		{Skip: 5},
+		{Skip: 2},
+		{Ref: &code},
+		{Ref: &data},
+	}
+	for _, f := range fields {
+		present, err := r.readBits(1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tonconnect: failed to parse state init: %w", err)
+		}
+		if present == 0 {
+			continue
+		}
+
+		if f.Ref == nil {
+			err = r.skipBits(f.Skip)
+		} else {
+			*f.Ref, err = r.readRef()
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("tonconnect: failed to parse state init: %w", err)
+		}
+	}
+
+	if code == nil || data == nil {
+		return nil, nil, fmt.Errorf("tonconnect: state init has no code or data")
+	}
+
+	return code, data, nil
+}
@@ -0,0 +1,136 @@
+package tonconnect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// State inits generated by tonutils-go for the public key testPublicKey and
+// the default subwallet.
+var testWallets = []struct {
+	Version   string
+	Address   string
+	StateInit string
+}{
+	{
+		Version:   "v3r2",
+		Address:   "0:e8275866cbf174de95888f0ad3373f99dbc883bec5f3df48503550bcea934337",
+		StateInit: "b5ee9c724101030100a000020134010200deff0020dd2082014c97ba218201339cbab19f71b0ed44d0d31fd31f31d70bffe304e0a4f2608308d71820d31fd31fd31ff82313bbf263ed44d0d31fd31fd3ffd15132baf2a15144baf2a204f901541055f910f2a3f8009320d74a96d307d402fb00e8d101a4c8cb1fcb1fcbffc9ed5400500000000029a9a31779b5562e8fe654f94078b112e8a98ba7901f853ae695bed7e0e3910bad04966449157c24",
+	},
+	{
+		Version:   "v4r2",
+		Address:   testV4R2Addr,
+		StateInit: testV4R2BoCHex,
+	},
+	{
+		Version:   "v5r1",
+		Address:   "0:861ecaab3f815458ace30bc660d99f9be7943705f4edf91452f3a59d0e5d90e8",
+		StateInit: "b5ee9c72410216010002b10002013401020114ff00f4a413f4bcf2c80b030051800000003fffae033cdaab1747f32a7ca03c58897454c5d3c80fc29d734adf6bf071c885d6824b3220020120040502014806070102f20802dcd020d749c120915b8f6320d70b1f2082106578746ebd21821073696e74bdb0925f03e082106578746eba8eb48020d72101d074d721fa4030fa44f828fa443058bd915be0ed44d0810141d721f4058307f40e6fa1319130e18040d721707fdb3ce03120d749810280b99130e070e212090201200a0b011e20d70b1f82107369676ebaf2e08a7f0901e68ef0eda2edfb218308d722028308d723208020d721d31fd31fd31fed44d0d200d31f20d31fd3ffd70a000af90140ccf9109a28945f0adb31e1f2c087df02b35007b0f2d0845125baf2e0855036baf2e086f823bbf2d0882292f800de01a47fc8ca00cb1f01cf16c9ed542092f80fde70db3cd8120201200c0d0019be5f0f6a2684080a0eb90fa02c02016e0e0f02014810110019adce76a2684020eb90eb85ffc00019af1df6a2684010eb90eb858fc00017b325fb51341c75c875c2c7e00011b262fb513435c2802003f6eda2edfb02f404216e926c218e4c0221d73930709421c700b38e2d01d72820761e436c20d749c008f2e09320d74ac002f2e09320d71d06c712c2005230b0f2d089d74cd7393001a4e86c128407bbf2e093d74ac000f2e093ed55e2d20001c000915be0ebd72c08142091709601d72c081c12e25210b1e30f20d74a131415009601fa4001fa44f828fa443058baf2e091ed44d0810141d718f405049d7fc8ca0040048307f453f2e08b8e14038307f45bf2e08c22d70a00216e01b3b0f2d090e2c85003cf1612f400c9ed54007230d72c08248e2d21f2e092d200ed44d0d2005113baf2d08f54503091319c01810140d721d70a00f2e08ee2c8ca0058cf16c9ed5493f2c08de20010935bdb31e1d74cd020497def",
+	},
+}
+
+func TestParseWalletStateInit(t *testing.T) {
+	pubkey := mustDecodeHex(t, testPublicKey)
+	for _, w := range testWallets {
+		t.Run(w.Version, func(t *testing.T) {
+			wsi, err := ParseWalletStateInit(w.Address, mustDecodeHex(t, w.StateInit))
+			if err != nil {
+				t.Fatalf("ParseWalletStateInit() error = %v", err)
+			}
+			if wsi.Version != w.Version {
+				t.Errorf("Version = %q, want %q", wsi.Version, w.Version)
+			}
+			if !bytes.Equal(wsi.PublicKey, pubkey) {
+				t.Errorf("PublicKey = %x, want %s", wsi.PublicKey, testPublicKey)
+			}
+		})
+	}
+}
+
+func TestVerifyProofWalletVersions(t *testing.T) {
+	key := testWalletKey()
+	ts := uint64(time.Now().Unix())
+	for _, w := range testWallets {
+		t.Run(w.Version, func(t *testing.T) {
+			res := &ConnectResponse{Items: []ConnectItemReply{
+				{Name: "ton_addr", TonAddrItem: TonAddrItem{
+					Address:         w.Address,
+					PublicKey:       testPublicKey,
+					WalletStateInit: mustDecodeHex(t, w.StateInit),
+				}},
+				{Name: "ton_proof", Proof: TonProofItem{
+					Timestamp: ts,
+					Domain:    TonProofDomain{LengthBytes: 11, Value: "example.com"},
+					Signature: signTestProof(key, 0, mustDecodeHex(t, w.Address[2:]), "example.com", ts, "nonce"),
+					Payload:   "nonce",
+				}},
+			}}
+
+			if err := VerifyProof(res, WithAllowedDomains("example.com")); err != nil {
+				t.Fatalf("VerifyProof() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseWalletStateInitErrors(t *testing.T) {
+	v3 := mustDecodeHex(t, testWallets[0].StateInit)
+
+	corrupted := bytes.Clone(v3)
+	corrupted[len(corrupted)/2] ^= 0xff
+
+	// A 25 byte header claiming 2^24 cells.
+	oversized := binary.BigEndian.AppendUint32(nil, bocMagic)
+	oversized = append(oversized, 0x04, 0x01)
+	oversized = binary.BigEndian.AppendUint32(oversized, 1<<24) // cells
+	oversized = binary.BigEndian.AppendUint32(oversized, 1)     // roots
+	oversized = binary.BigEndian.AppendUint32(oversized, 0)     // absent
+	oversized = append(oversized, 0xff)                         // data size
+	oversized = binary.BigEndian.AppendUint32(oversized, 0)     // root index
+	oversized = append(oversized, 0, 0)
+
+	tests := []struct {
+		name      string
+		address   string
+		stateInit []byte
+	}{
+		{name: "empty", address: testWallets[0].Address},
+		{name: "truncated", address: testWallets[0].Address, stateInit: v3[:len(v3)/2]},
+		{name: "bad checksum", address: testWallets[0].Address, stateInit: corrupted},
+		{name: "oversized cell count", address: testWallets[0].Address, stateInit: oversized},
+		{name: "address mismatch", address: testWallets[1].Address, stateInit: v3},
+		{name: "malformed address", address: "0:" + hex.EncodeToString([]byte("short")), stateInit: v3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWalletStateInit(tt.address, tt.stateInit); err == nil {
+				t.Fatal("ParseWalletStateInit() error = nil")
+			}
+		})
+	}
+}
+
+func TestParseBoCLimitsCellCount(t *testing.T) {
+	for _, cells := range []uint32{maxBoCCells + 1, 1 << 24, 1 << 31} {
+		boc := binary.BigEndian.AppendUint32(nil, bocMagic)
+		boc = append(boc, 0x04, 0x04)
+		boc = binary.BigEndian.AppendUint32(boc, cells)
+		boc = binary.BigEndian.AppendUint32(boc, 1)
+		boc = binary.BigEndian.AppendUint32(boc, 0)
+		boc = binary.BigEndian.AppendUint32(boc, cells*2)
+		boc = binary.BigEndian.AppendUint32(boc, 0)
+
+		allocs := testing.AllocsPerRun(1, func() {
+			if _, err := parseBoC(boc); err == nil {
+				t.Fatalf("parseBoC() with %d cells error = nil", cells)
+			}
+		})
+		if allocs > 10 {
+			t.Errorf("parseBoC() with %d cells made %v allocations", cells, allocs)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package tonconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SessionStore persists serialized sessions by key.
+type SessionStore interface {
+	Load(ctx context.Context, key string) ([]byte, error)
+	Save(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+var ErrSessionNotFound = errors.New("tonconnect: session not found")
+
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+type FileStore struct {
+	dir string
+}
+
+// WithStore makes the session save itself under key every time its state
+// changes.
+func WithStore(store SessionStore, key string) sessionOpt {
+	return func(s *Session) {
+		s.store = store
+		s.storeKey = key
+	}
+}
+
+func LoadSession(ctx context.Context, store SessionStore, key string, options ...sessionOpt) (*Session, error) {
+	data, err := store.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	WithStore(store, key)(s)
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to unmarshal session: %w", err)
+	}
+
+	// Write sessions encoded with an older schema back in the current one.
+	var v struct {
+		Version uint64 `json:"version"`
+	}
+	if err := json.Unmarshal(data, &v); err == nil && v.Version < sessionVersion {
+		if err := s.checkpoint(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Session) checkpoint(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+
+	// Serialize saves so an older snapshot never overwrites a newer one.
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("tonconnect: failed to marshal session: %w", err)
+	}
+
+	if err := s.store.Save(context.WithoutCancel(ctx), s.storeKey, data); err != nil {
+		return fmt.Errorf("tonconnect: failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string][]byte)}
+}
+
+func (m *MemoryStore) Load(_ context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.sessions[key]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+func (m *MemoryStore) Save(_ context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, key)
+
+	return nil
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to create session store directory: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (f *FileStore) Load(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to read session file: %w", err)
+	}
+
+	return data, nil
+}
+
+func (f *FileStore) Save(_ context.Context, key string, data []byte) error {
+	tmp, err := os.CreateTemp(f.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("tonconnect: failed to create session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("tonconnect: failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("tonconnect: failed to write session file: %w", err)
+	}
+
+	// Rename is atomic, so a crash never leaves a half-written session behind.
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("tonconnect: failed to write session file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FileStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(f.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("tonconnect: failed to delete session file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.dir, url.PathEscape(key)+".json")
+}
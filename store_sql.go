@@ -0,0 +1,129 @@
+package tonconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SQLStore keeps sessions in a table with the following layout, using BYTEA
+// instead of BLOB on PostgreSQL:
+//
+//	CREATE TABLE tonconnect_sessions (
+//		id   VARCHAR(255) PRIMARY KEY,
+//		data BLOB NOT NULL
+//	)
+type SQLStore struct {
+	db       *sql.DB
+	table    string
+	dialect  SQLDialect
+	dollarPH bool
+}
+
+// SQLDialect selects the upsert syntax and placeholders of the database.
+type SQLDialect int
+
+const (
+	DialectSQLite SQLDialect = iota
+	DialectPostgres
+	DialectMySQL
+)
+
+type sqlStoreOpt = func(*SQLStore)
+
+func NewSQLStore(db *sql.DB, options ...sqlStoreOpt) *SQLStore {
+	store := &SQLStore{db: db, table: "tonconnect_sessions"}
+	for _, opt := range options {
+		opt(store)
+	}
+
+	return store
+}
+
+func (st *SQLStore) Load(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := st.db.QueryRowContext(ctx, st.query("SELECT data FROM %s WHERE id = ?"), key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tonconnect: failed to load session: %w", err)
+	}
+
+	return data, nil
+}
+
+// Save inserts or replaces the session in a single upsert statement, so
+// concurrent saves of the same key can't conflict.
+func (st *SQLStore) Save(ctx context.Context, key string, data []byte) error {
+	_, err := st.db.ExecContext(ctx, st.upsertQuery(), key, data)
+	if err != nil {
+		return fmt.Errorf("tonconnect: failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+func (st *SQLStore) Delete(ctx context.Context, key string) error {
+	_, err := st.db.ExecContext(ctx, st.query("DELETE FROM %s WHERE id = ?"), key)
+	if err != nil {
+		return fmt.Errorf("tonconnect: failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+func (st *SQLStore) upsertQuery() string {
+	if st.dialect == DialectMySQL {
+		return st.query("INSERT INTO %s (id, data) VALUES (?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data)")
+	}
+
+	return st.query("INSERT INTO %s (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data")
+}
+
+func (st *SQLStore) query(format string) string {
+	q := fmt.Sprintf(format, st.table)
+	if !st.dollarPH {
+		return q
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func WithTable(table string) sqlStoreOpt {
+	return func(st *SQLStore) {
+		st.table = table
+	}
+}
+
+// WithDialect sets the database dialect, SQLite by default. PostgreSQL
+// implies dollar placeholders.
+func WithDialect(dialect SQLDialect) sqlStoreOpt {
+	return func(st *SQLStore) {
+		st.dialect = dialect
+		if dialect == DialectPostgres {
+			st.dollarPH = true
+		}
+	}
+}
+
+// WithDollarPlaceholders switches queries to $1, $2, ... placeholders used by
+// PostgreSQL drivers.
+func WithDollarPlaceholders() sqlStoreOpt {
+	return func(st *SQLStore) {
+		st.dollarPH = true
+	}
+}
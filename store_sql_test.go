@@ -0,0 +1,36 @@
+package tonconnect
+
+import (
+	"testing"
+)
+
+func TestSQLStoreUpsertQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []sqlStoreOpt
+		want    string
+	}{
+		{
+			name: "sqlite",
+			want: "INSERT INTO tonconnect_sessions (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data",
+		},
+		{
+			name:    "postgres",
+			options: []sqlStoreOpt{WithDialect(DialectPostgres), WithTable("sessions")},
+			want:    "INSERT INTO sessions (id, data) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET data = excluded.data",
+		},
+		{
+			name:    "mysql",
+			options: []sqlStoreOpt{WithDialect(DialectMySQL)},
+			want:    "INSERT INTO tonconnect_sessions (id, data) VALUES (?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSQLStore(nil, tt.options...).upsertQuery(); got != tt.want {
+				t.Errorf("upsertQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
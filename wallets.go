@@ -5,38 +5,132 @@ import (
 )
 
 type Wallet struct {
-	Name         string `json:"name"`
-	UniversalURL string `json:"universal_url"`
-	BridgeURL    string `json:"bridge_url"`
+	AppName      string         `json:"app_name,omitempty"`
+	Name         string         `json:"name"`
+	Image        string         `json:"image,omitempty"`
+	UniversalURL string         `json:"universal_url"`
+	DeepLink     string         `json:"deepLink,omitempty"`
+	BridgeURL    string         `json:"bridge_url,omitempty"`
+	Bridges      []WalletBridge `json:"bridge,omitempty"`
+	Platforms    []string       `json:"platforms,omitempty"`
+	Features     Features       `json:"features,omitempty"`
+	TonDNS       string         `json:"tondns,omitempty"`
+	AboutURL     string         `json:"about_url,omitempty"`
 }
 
+// WalletBridge is either an HTTP bridge with a URL (type "sse") or a wallet
+// injected into the web page under window[Key] (type "js").
+type WalletBridge struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+const (
+	BridgeTypeSSE = "sse"
+	BridgeTypeJS  = "js"
+)
+
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformMacOS   = "macos"
+	PlatformWindows = "windows"
+	PlatformLinux   = "linux"
+	PlatformChrome  = "chrome"
+	PlatformFirefox = "firefox"
+	PlatformSafari  = "safari"
+)
+
+// Wallets is the fallback list used when the wallet registry is unavailable.
+// Features are left unset rather than guessed, since they change with wallet
+// releases, so feature filters only match wallets of a loaded registry.
 var Wallets = map[string]Wallet{
 	"telegram-wallet": {
+		AppName:      "telegram-wallet",
 		Name:         "Wallet",
 		UniversalURL: "https://t.me/wallet/start?startapp=",
 		BridgeURL:    "https://bridge.ton.space/bridge",
+		AboutURL:     "https://wallet.tg/",
+		Platforms:    []string{PlatformIOS, PlatformAndroid, PlatformMacOS, PlatformWindows, PlatformLinux},
 	},
 	"tonkeeper": {
+		AppName:      "tonkeeper",
 		Name:         "Tonkeeper",
 		UniversalURL: "https://app.tonkeeper.com/ton-connect",
 		BridgeURL:    "https://bridge.tonapi.io/bridge",
+		Bridges:      []WalletBridge{{Type: BridgeTypeJS, Key: "tonkeeper"}},
+		TonDNS:       "tonkeeper.ton",
+		AboutURL:     "https://tonkeeper.com",
+		Platforms:    []string{PlatformIOS, PlatformAndroid, PlatformChrome, PlatformFirefox, PlatformMacOS},
 	},
 	"mytonwallet": {
+		AppName:      "mytonwallet",
 		Name:         "MyTonWallet",
 		UniversalURL: "https://connect.mytonwallet.org/",
 		BridgeURL:    "https://tonconnectbridge.mytonwallet.org/bridge",
+		Bridges:      []WalletBridge{{Type: BridgeTypeJS, Key: "mytonwallet"}},
+		AboutURL:     "https://mytonwallet.io",
+		Platforms:    []string{PlatformChrome, PlatformWindows, PlatformMacOS, PlatformLinux, PlatformIOS, PlatformAndroid, PlatformFirefox},
 	},
 	"tonhub": {
+		AppName:      "tonhub",
 		Name:         "Tonhub",
 		UniversalURL: "https://tonhub.com/ton-connect",
 		BridgeURL:    "https://connect.tonhubapi.com/tonconnect",
+		Bridges:      []WalletBridge{{Type: BridgeTypeJS, Key: "tonhub"}},
+		AboutURL:     "https://tonhub.com",
+		Platforms:    []string{PlatformIOS, PlatformAndroid},
 	},
 }
 
+// SupportsPlatform reports whether the wallet is available on platform.
+func (w Wallet) SupportsPlatform(platform string) bool {
+	return slices.Contains(w.Platforms, platform)
+}
+
+// HasBridge reports whether the wallet can be reached over a bridge of the
+// given type.
+func (w Wallet) HasBridge(typ string) bool {
+	if typ == BridgeTypeSSE && w.BridgeURL != "" {
+		return true
+	}
+
+	return slices.ContainsFunc(w.Bridges, func(b WalletBridge) bool {
+		return b.Type == typ
+	})
+}
+
+// InjectedKey returns the window property the wallet is injected under by
+// its browser extension or in-app browser.
+func (w Wallet) InjectedKey() (string, bool) {
+	for _, b := range w.Bridges {
+		if b.Type == BridgeTypeJS && b.Key != "" {
+			return b.Key, true
+		}
+	}
+
+	return "", false
+}
+
+func (w Wallet) sseBridgeURL() string {
+	for _, b := range w.Bridges {
+		if b.Type == BridgeTypeSSE && b.URL != "" {
+			return b.URL
+		}
+	}
+
+	return w.BridgeURL
+}
+
+// getBridgeURLs returns the SSE bridges of wallets, wallets reachable only
+// through a JS bridge are skipped.
 func getBridgeURLs(wallets ...Wallet) []string {
 	var bridges []string
 	for _, w := range wallets {
-		bridges = append(bridges, w.BridgeURL)
+		if u := w.sseBridgeURL(); u != "" {
+			bridges = append(bridges, u)
+		}
 	}
 
 	slices.Sort(bridges)